@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"github.com/nosikmy/music-library/internal/app/services"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cliActor is recorded in the audit log for the changes made from the command line, imports can name another one
+const cliActor = "cli"
+
+// runImport imports songs from a CSV or JSON Lines file given in the args, the report is printed to stdout.
+// Songs without lyrics are enriched by the workers of the running server
+func runImport(importService *services.ImportService, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: music-library import [-format csv|ndjson|json] [-v] [-actor name] <file>")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "format of the rows, taken from the file extension if not set")
+	verbose := flags.Bool("v", false, "print every row, not only the failed ones")
+	actor := flags.String("actor", cliActor, "name recorded in the audit log as the one adding the songs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("one file is expected")
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = services.ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			*format = services.ImportFormatNDJSON
+		case ".json":
+			*format = services.ImportFormatJSON
+		default:
+			return fmt.Errorf("unknown format of %s, set it with -format", path)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := importService.Import(*format, file, models.Actor{Name: *actor})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		switch {
+		case row.Status == models.ImportStatusFailed:
+			fmt.Printf("row %d (%s - %s): failed: %s\n", row.Row, row.Group, row.Song, row.Error)
+		case *verbose && row.JobId != 0:
+			fmt.Printf("row %d (%s - %s): %s as song %d, enrichment job %d\n", row.Row, row.Group, row.Song,
+				row.Status, row.Id, row.JobId)
+		case *verbose:
+			fmt.Printf("row %d (%s - %s): %s as song %d\n", row.Row, row.Group, row.Song, row.Status, row.Id)
+		}
+	}
+	fmt.Printf("created: %d, existing: %d, failed: %d\n", report.Created, report.Existing, report.Failed)
+	return nil
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/joho/godotenv"
 	"github.com/nosikmy/music-library/internal/app/handler"
+	"github.com/nosikmy/music-library/internal/app/policy"
+	"github.com/nosikmy/music-library/internal/app/provider"
+	"github.com/nosikmy/music-library/internal/app/ratelimit"
 	"github.com/nosikmy/music-library/internal/app/repository"
 	"github.com/nosikmy/music-library/internal/app/server"
 	"github.com/nosikmy/music-library/internal/app/services"
@@ -14,6 +18,8 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 )
 
@@ -21,8 +27,21 @@ import (
 //	@version		1.0
 //	@description	API Server for Music Library Service
 
+// enrichmentWorkers is the number of workers filling draft songs with their metadata
+const enrichmentWorkers = 2
+
 // @host		localhost:8080
 // @BasePath	/
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Access token from /auth/login as "Bearer <token>"
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						Authorization
+// @description				API key from /apikey as "ApiKey <key>"
 func main() {
 
 	if err := godotenv.Load(); err != nil {
@@ -50,18 +69,114 @@ func main() {
 	libraryRepository := repository.NewLibraryRepository(db)
 	songRepository := repository.NewSongRepository(db)
 	songChangerRepository := repository.NewSongChangerRepository(db)
-	versesRepository := repository.NewVersesRepository(db)
+	albumRepository := repository.NewAlbumRepository(db)
+	groupRepository := repository.NewGroupRepository(db)
+	playlistRepository := repository.NewPlaylistRepository(db)
+	revisionRepository := repository.NewRevisionRepository(db)
+	trashRepository := repository.NewTrashRepository(db)
+	jobRepository := repository.NewJobRepository(db)
+	userRepository := repository.NewUserRepository(db)
+	apiKeyRepository := repository.NewApiKeyRepository(db)
+	auditRepository := repository.NewAuditRepository(db)
+
+	var metadataProvider services.MetadataProvider
+	switch os.Getenv("METADATA_PROVIDER") {
+	case "fake":
+		myLogger.Info("Using fake metadata provider")
+		metadataProvider = provider.NewFakeProvider()
+	default:
+		metadataProvider = provider.NewHTTPProvider(provider.Config{
+			Address: os.Getenv("API_MUSIC_ADDRESS"),
+		})
+	}
+
+	rolePolicy, err := policy.Load(os.Getenv("POLICY_FILE"))
+	if err != nil {
+		myLogger.Error("Error occured while loading policy: " + err.Error())
+		return
+	}
 
 	libraryService := services.NewLibraryService(myLogger, libraryRepository)
-	songService := services.NewSongService(myLogger, songRepository, songChangerRepository, versesRepository)
+	songService := services.NewSongService(myLogger, songRepository, songChangerRepository, revisionRepository,
+		metadataProvider)
+	albumService := services.NewAlbumService(myLogger, albumRepository)
+	groupService := services.NewGroupService(myLogger, groupRepository)
+	playlistService := services.NewPlaylistService(myLogger, playlistRepository)
+	trashService := services.NewTrashService(myLogger, trashRepository)
+	enrichmentService := services.NewEnrichmentService(myLogger, jobRepository, songRepository, metadataProvider)
+	importService := services.NewImportService(myLogger, songRepository, jobRepository)
+	userService := services.NewUserService(myLogger, userRepository, rolePolicy)
+	apiKeyService := services.NewApiKeyService(myLogger, apiKeyRepository)
+	auditService := services.NewAuditService(myLogger, auditRepository)
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err = runImport(importService, os.Args[2:])
+		if closeErr := db.Close(); closeErr != nil {
+			myLogger.Error("Can't close DB connection: %s" + closeErr.Error())
+		}
+		if err != nil {
+			log.Fatalln("Import failed: " + err.Error())
+		}
+		return
+	}
 
-	handlers := handler.NewHandler(myLogger, libraryService, songService)
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		err = runRole(userService, os.Args[2:])
+		if closeErr := db.Close(); closeErr != nil {
+			myLogger.Error("Can't close DB connection: %s" + closeErr.Error())
+		}
+		if err != nil {
+			log.Fatalln("Changing role failed: " + err.Error())
+		}
+		return
+	}
+
+	authService, err := services.NewAuthService(myLogger, userRepository, services.AuthConfig{
+		Secret: os.Getenv("JWT_SECRET"),
+	})
+	if err != nil {
+		myLogger.Error("Error occured while init auth: " + err.Error())
+		return
+	}
+
+	ipLimit, err := rateLimitConfig("IP", ratelimit.DefaultIPConfig)
+	if err != nil {
+		myLogger.Error("Error occured while reading rate limits: " + err.Error())
+		return
+	}
+	readLimit, err := rateLimitConfig("READ", ratelimit.DefaultReadConfig)
+	if err != nil {
+		myLogger.Error("Error occured while reading rate limits: " + err.Error())
+		return
+	}
+	writeLimit, err := rateLimitConfig("WRITE", ratelimit.DefaultWriteConfig)
+	if err != nil {
+		myLogger.Error("Error occured while reading rate limits: " + err.Error())
+		return
+	}
+
+	handlers := handler.NewHandler(myLogger, rolePolicy, ratelimit.New(ipLimit), ratelimit.New(readLimit),
+		ratelimit.New(writeLimit), authService, userService, apiKeyService, libraryService, songService,
+		albumService, groupService, playlistService, trashService, enrichmentService, importService, auditService)
+
+	router, err := handlers.InitRoutes(trustedProxies())
+	if err != nil {
+		myLogger.Error("Error occured while init routes: " + err.Error())
+		return
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		enrichmentService.Run(workersCtx, enrichmentWorkers)
+		close(workersDone)
+	}()
 
 	srv := new(server.Server)
 	bindAddr := os.Getenv("BIND_ADDR")
 
 	go func() {
-		if err := srv.Run(bindAddr, handlers.InitRoutes()); err != nil {
+		if err := srv.Run(bindAddr, router); err != nil {
 			myLogger.Error("Error while running server" + err.Error())
 			return
 		}
@@ -77,7 +192,41 @@ func main() {
 	if err := srv.Shutdown(context.Background()); err != nil {
 		myLogger.Error("Can't terminate server: %s" + err.Error())
 	}
+	stopWorkers()
+	<-workersDone
 	if err := db.Close(); err != nil {
 		myLogger.Error("Can't close DB connection: %s" + err.Error())
 	}
 }
+
+// rateLimitConfig reads the <budget>_RATE_LIMIT (requests per minute) and <budget>_RATE_BURST env variables,
+// the unset ones keep the default
+func rateLimitConfig(budget string, def ratelimit.Config) (ratelimit.Config, error) {
+	cfg := def
+	for name, value := range map[string]*int{
+		budget + "_RATE_LIMIT": &cfg.Rate,
+		budget + "_RATE_BURST": &cfg.Burst,
+	} {
+		str := os.Getenv(name)
+		if str == "" {
+			continue
+		}
+		n, err := strconv.Atoi(str)
+		if err != nil || n <= 0 {
+			return ratelimit.Config{}, fmt.Errorf("%s must be a positive number, got %q", name, str)
+		}
+		*value = n
+	}
+	return cfg, nil
+}
+
+// trustedProxies reads the comma-separated TRUSTED_PROXIES env variable, no proxy is trusted if it is not set
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
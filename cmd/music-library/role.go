@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"github.com/nosikmy/music-library/internal/app/services"
+)
+
+// runRole gives the user named in the args a role, the way to make the first admin.
+// The change is recorded in the audit log as made by the cli
+func runRole(userService *services.UserService, args []string) error {
+	flags := flag.NewFlagSet("role", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: music-library role <username> <role>")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("username and role are expected")
+	}
+
+	username, role := flags.Arg(0), flags.Arg(1)
+	if err := userService.ChangeRoleByName(username, role, models.Actor{Name: cliActor}); err != nil {
+		return err
+	}
+	fmt.Printf("%s is %s now\n", username, role)
+	return nil
+}
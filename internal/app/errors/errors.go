@@ -24,6 +24,38 @@ var (
 		Status:  http.StatusBadRequest,
 		Message: "bad request error",
 	}
+	UnauthorizedError = MusicLibraryError{
+		Status:  http.StatusUnauthorized,
+		Message: "unauthorized error",
+	}
+	ForbiddenError = MusicLibraryError{
+		Status:  http.StatusForbidden,
+		Message: "forbidden error",
+	}
+	NotFoundError = MusicLibraryError{
+		Status:  http.StatusNotFound,
+		Message: "not found error",
+	}
+	ConflictError = MusicLibraryError{
+		Status:  http.StatusConflict,
+		Message: "conflict error",
+	}
+	PreconditionFailedError = MusicLibraryError{
+		Status:  http.StatusPreconditionFailed,
+		Message: "precondition failed error",
+	}
+	TooManyRequestsError = MusicLibraryError{
+		Status:  http.StatusTooManyRequests,
+		Message: "too many requests error",
+	}
+	BadGatewayError = MusicLibraryError{
+		Status:  http.StatusBadGateway,
+		Message: "bad gateway error",
+	}
+	ServiceUnavailableError = MusicLibraryError{
+		Status:  http.StatusServiceUnavailable,
+		Message: "service unavailable error",
+	}
 )
 
 func NewMusicLibraryError(merr MusicLibraryError, err error) error {
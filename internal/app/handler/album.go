@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetAlbums Handler to get a list of albums
+//
+//	@Summary		Get a list of albums
+//	@Description	Supports pagination(limit, page params)
+//	@Tags			album
+//	@Produce		json
+//	@Param			limit				query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int	false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200					{object}	models.AlbumsResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/album [get]
+func (h *Handler) GetAlbums(ctx *gin.Context) {
+	const op = "handler.album.GetAlbums"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting albums")
+
+	count, albums, err := h.albumService.GetAlbums(limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting albums " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got albums", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":  count,
+			"albums": albums,
+		},
+	})
+}
+
+// GetAlbum Handler to get an album with its tracks
+//
+//	@Summary	Get an album with its tracks
+//	@Tags		album
+//	@Produce	json
+//	@Param		id						path		int	true	"id of the chosen album"
+//	@Success	200						{object}	models.AlbumResponse
+//	@Failure	400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/album/{id} [get]
+func (h *Handler) GetAlbum(ctx *gin.Context) {
+	const op = "handler.album.GetAlbum"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting album", slog.Int("id", id))
+
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		h.logger.Error("Error while getting album " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got album", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: album,
+	})
+}
+
+// AddAlbum Handler to add an album
+//
+//	@Summary	Add an album
+//	@Tags		album
+//	@Accept		json
+//	@Produce	json
+//	@Param		input				body		models.AlbumRequest	true	"Data for adding an album"
+//	@Success	200					{object}	models.AddAlbumResponse
+//	@Failure	400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/album [post]
+func (h *Handler) AddAlbum(ctx *gin.Context) {
+	const op = "handler.album.AddAlbum"
+	var input models.AlbumRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+	releaseDate, err := parseAlbumDate(input.ReleaseDate)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad date format"))
+		return
+	}
+
+	h.logger.Info("Adding new album", slog.String("name", input.Name))
+
+	id, err := h.albumService.AddAlbum(input.Name, releaseDate, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while adding album " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("New album added", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"id": id,
+		},
+	})
+}
+
+// ChangeAlbum Handler to change the name and release date of an album
+//
+//	@Summary	Change the name and release date of an album
+//	@Tags		album
+//	@Accept		json
+//	@Produce	json
+//	@Param		id						path		int					true	"id of the chosen album"
+//	@Param		input					body		models.AlbumRequest	true	"New album data"
+//	@Success	200						{object}	models.Response
+//	@Failure	400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/album/{id} [put]
+func (h *Handler) ChangeAlbum(ctx *gin.Context) {
+	const op = "handler.album.ChangeAlbum"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.AlbumRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+	releaseDate, err := parseAlbumDate(input.ReleaseDate)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad date format"))
+		return
+	}
+
+	h.logger.Info("Changing album", slog.Int("id", id))
+
+	err = h.albumService.ChangeAlbum(id, input.Name, releaseDate, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while changing album " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Album changed", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// DeleteAlbum Handler to delete an album
+//
+//	@Summary		Delete an album
+//	@Description	Songs of the album stay in the library
+//	@Tags			album
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen album"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/album/{id} [delete]
+func (h *Handler) DeleteAlbum(ctx *gin.Context) {
+	const op = "handler.album.DeleteAlbum"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Deleting album", slog.Int("id", id))
+
+	err = h.albumService.DeleteAlbum(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while deleting album " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Album deleted", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// AddTrack Handler to put a song on an album
+//
+//	@Summary		Put a song on an album
+//	@Description	If the song is already on the album, its disc and track numbers are updated
+//	@Description	Disc and track numbers start from 1, a position taken by another song is a conflict
+//	@Tags			album
+//	@Accept			json
+//	@Produce		json
+//	@Param			id							path		int							true	"id of the chosen album"
+//	@Param			input						body		models.AlbumTrackRequest	true	"Song and its position on the album"
+//	@Success		200							{object}	models.Response
+//	@Failure		400,401,403,404,409,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/album/{id}/tracks [post]
+func (h *Handler) AddTrack(ctx *gin.Context) {
+	const op = "handler.album.AddTrack"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.AlbumTrackRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Adding track to album", slog.Int("id", id), slog.Int("songId", input.SongId))
+
+	err = h.albumService.AddTrack(id, models.AlbumTrack{
+		DiscNumber:  input.DiscNumber,
+		TrackNumber: input.TrackNumber,
+		SongId:      input.SongId,
+	}, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while adding track " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Track added to album", slog.Int("id", id), slog.Int("songId", input.SongId))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// DeleteTrack Handler to remove a song from an album
+//
+//	@Summary	Remove a song from an album
+//	@Tags		album
+//	@Produce	json
+//	@Param		id						path		int	true	"id of the chosen album"
+//	@Param		songId					path		int	true	"id of the song to be removed"
+//	@Success	200						{object}	models.Response
+//	@Failure	400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/album/{id}/tracks/{songId} [delete]
+func (h *Handler) DeleteTrack(ctx *gin.Context) {
+	const op = "handler.album.DeleteTrack"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	songId, err := strconv.Atoi(ctx.Param("songId"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "songId is not a number"))
+		return
+	}
+
+	h.logger.Info("Deleting track from album", slog.Int("id", id), slog.Int("songId", songId))
+
+	err = h.albumService.DeleteTrack(id, songId, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while deleting track " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Track deleted from album", slog.Int("id", id), slog.Int("songId", songId))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+func parseAlbumDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("02.01.2006", date)
+}
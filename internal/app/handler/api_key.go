@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// CreateApiKey Handler to create an API key
+//
+//	@Summary		Create an API key
+//	@Description	The key is returned only once, it is sent in the "Authorization: ApiKey <key>" header
+//	@Description	Scopes are library:read, song:write and song:delete
+//	@Tags			apikey
+//	@Accept			json
+//	@Produce		json
+//	@Param			input				body		models.ApiKeyRequest	true	"Name, scopes and optional expiry of the key"
+//	@Success		200					{object}	models.ApiKeyCreatedResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/apikey [post]
+func (h *Handler) CreateApiKey(ctx *gin.Context) {
+	const op = "handler.api_key.CreateApiKey"
+	var input models.ApiKeyRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+	user, _ := currentUser(ctx)
+
+	h.logger.Info("Creating API key", slog.String("name", input.Name), slog.Any("scopes", input.Scopes))
+
+	id, key, err := h.apiKeyService.CreateApiKey(input.Name, input.Scopes, input.ExpiresAt, user.Id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while creating API key " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("API key created", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"id":  id,
+			"key": key,
+		},
+	})
+}
+
+// GetApiKeys Handler to get a list of API keys
+//
+//	@Summary		Get a list of API keys
+//	@Description	Revoked keys are listed too, the keys themselves are never returned
+//	@Description	Supports pagination(limit, page params)
+//	@Tags			apikey
+//	@Produce		json
+//	@Param			limit				query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int	false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200					{object}	models.ApiKeysResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/apikey [get]
+func (h *Handler) GetApiKeys(ctx *gin.Context) {
+	const op = "handler.api_key.GetApiKeys"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting API keys")
+
+	count, keys, err := h.apiKeyService.GetApiKeys(limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting API keys " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got API keys", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":   count,
+			"apiKeys": keys,
+		},
+	})
+}
+
+// RevokeApiKey Handler to revoke an API key
+//
+//	@Summary		Revoke an API key
+//	@Description	The key stops working at once and can not be rotated any more
+//	@Tags			apikey
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen key"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/apikey/{id} [delete]
+func (h *Handler) RevokeApiKey(ctx *gin.Context) {
+	const op = "handler.api_key.RevokeApiKey"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Revoking API key", slog.Int("id", id))
+
+	err = h.apiKeyService.RevokeApiKey(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while revoking API key " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("API key revoked", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// RotateApiKey Handler to rotate an API key
+//
+//	@Summary		Rotate an API key
+//	@Description	Replaces the key with a new one that keeps the name, scopes and expiry, the old key stops working at once
+//	@Tags			apikey
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen key"
+//	@Success		200						{object}	models.ApiKeyCreatedResponse
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/apikey/{id}/rotate [post]
+func (h *Handler) RotateApiKey(ctx *gin.Context) {
+	const op = "handler.api_key.RotateApiKey"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Rotating API key", slog.Int("id", id))
+
+	key, err := h.apiKeyService.RotateApiKey(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while rotating API key " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("API key rotated", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"id":  id,
+			"key": key,
+		},
+	})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// requestIdHeader carries the id of the request, a valid id sent by the client is kept
+	requestIdHeader = "X-Request-Id"
+	// requestIdContextKey is the key of the id of the request in the gin context
+	requestIdContextKey = "requestId"
+	// auditDateLayout is the layout of a day in the period of the audit log, a time is in RFC 3339
+	auditDateLayout = "2006-01-02"
+)
+
+// validRequestId matches the ids of requests kept from the clients
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// GetAuditEvents Handler to get the audit log
+//
+//	@Summary		Get the audit log
+//	@Description	Returns the changes of songs, groups, albums, playlists, users and API keys
+//	@Description	with their state before and after, the latest first
+//	@Description	Supports filtration(entity, id, actor, from, to params) and pagination(limit, page params)
+//	@Description	from and to are days like 2026-07-01 or times in RFC 3339, from is included and to is not
+//	@Tags			audit
+//	@Produce		json
+//	@Param			entity				query		string	false	"type of the changed entity"	Enums(song, group, album, playlist, user, apikey)
+//	@Param			id					query		int		false	"id of the changed entity, needs entity"
+//	@Param			actor				query		string	false	"user or apikey:<name> that made the changes"
+//	@Param			from				query		string	false	"start of the period"					example(2026-07-01)
+//	@Param			to					query		string	false	"end of the period"						example(2026-10-01)
+//	@Param			limit				query		int		false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int		false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200					{object}	models.AuditEventsResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/audit [get]
+func (h *Handler) GetAuditEvents(ctx *gin.Context) {
+	const op = "handler.audit.GetAuditEvents"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	filter := models.AuditFilter{
+		EntityType: ctx.Query("entity"),
+		Actor:      ctx.Query("actor"),
+	}
+	if idStr := ctx.Query("id"); idStr != "" {
+		if filter.EntityId, err = strconv.Atoi(idStr); err != nil {
+			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+			return
+		}
+	}
+	if filter.From, err = parseAuditTime(ctx.Query("from")); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of from"))
+		return
+	}
+	if filter.To, err = parseAuditTime(ctx.Query("to")); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of to"))
+		return
+	}
+
+	h.logger.Info("Getting audit events", slog.String("entity", filter.EntityType),
+		slog.Int("entityId", filter.EntityId), slog.String("actor", filter.Actor))
+
+	count, events, err := h.auditService.GetAuditEvents(filter, limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting audit events " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got audit events", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":  count,
+			"events": events,
+		},
+	})
+}
+
+// parseAuditTime parses a day or a time in RFC 3339, an empty value is the zero time
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(auditDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a day like %s nor a time in RFC 3339", value, auditDateLayout)
+	}
+	return t, nil
+}
+
+// assignRequestId is a middleware that gives the request an id and returns it in the X-Request-Id header.
+// The id is recorded in the audit log with the changes made by the request
+func (h *Handler) assignRequestId(ctx *gin.Context) {
+	id := ctx.GetHeader(requestIdHeader)
+	if !validRequestId.MatchString(id) {
+		data := make([]byte, 8)
+		if _, err := rand.Read(data); err != nil {
+			h.logger.Error("Failed to generate request id: " + err.Error())
+		}
+		id = hex.EncodeToString(data)
+	}
+	ctx.Set(requestIdContextKey, id)
+	ctx.Header(requestIdHeader, id)
+	ctx.Next()
+}
+
+// auditActor returns the user or API key making the request and the id of the request
+func auditActor(ctx *gin.Context) models.Actor {
+	return models.Actor{
+		Name:      editorName(ctx),
+		RequestId: ctx.GetString(requestIdContextKey),
+	}
+}
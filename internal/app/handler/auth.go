@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// userContextKey is the key of the authenticated user in the gin context
+	userContextKey = "user"
+	// apiKeyContextKey is the key of the API key of the request in the gin context
+	apiKeyContextKey = "apiKey"
+	// apiKeyScheme is the scheme of the Authorization header with an API key
+	apiKeyScheme = "ApiKey"
+)
+
+// Register Handler to register a user
+//
+//	@Summary	Register a user
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input			body		models.CredentialsRequest	true	"Username and password, the password is 8 to 72 characters long"
+//	@Success	200				{object}	models.RegisterResponse
+//	@Failure	400,409,429,500	{object}	errors.MusicLibraryError
+//	@Router		/auth/register [post]
+func (h *Handler) Register(ctx *gin.Context) {
+	const op = "handler.auth.Register"
+	var input models.CredentialsRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Registering user", slog.String("username", input.Username))
+
+	id, err := h.authService.Register(input.Username, input.Password, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while registering user " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("User registered", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"id": id,
+		},
+	})
+}
+
+// Login Handler to log a user in
+//
+//	@Summary		Log in
+//	@Description	Returns an access token for the Authorization header and a refresh token for /auth/refresh
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input			body		models.CredentialsRequest	true	"Username and password"
+//	@Success		200				{object}	models.TokensResponse
+//	@Failure		400,401,429,500	{object}	errors.MusicLibraryError
+//	@Router			/auth/login [post]
+func (h *Handler) Login(ctx *gin.Context) {
+	const op = "handler.auth.Login"
+	var input models.CredentialsRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Logging in", slog.String("username", input.Username))
+
+	tokens, err := h.authService.Login(input.Username, input.Password)
+	if err != nil {
+		h.logger.Error("Error while logging in " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Logged in", slog.String("username", input.Username))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: tokens,
+	})
+}
+
+// Refresh Handler to refresh the tokens of a user
+//
+//	@Summary		Refresh tokens
+//	@Description	Exchanges a refresh token for a new pair of tokens
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input			body		models.RefreshRequest	true	"Refresh token"
+//	@Success		200				{object}	models.TokensResponse
+//	@Failure		400,401,429,500	{object}	errors.MusicLibraryError
+//	@Router			/auth/refresh [post]
+func (h *Handler) Refresh(ctx *gin.Context) {
+	const op = "handler.auth.Refresh"
+	var input models.RefreshRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Refreshing tokens")
+
+	tokens, err := h.authService.Refresh(input.RefreshToken)
+	if err != nil {
+		h.logger.Error("Error while refreshing tokens " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Tokens refreshed")
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: tokens,
+	})
+}
+
+// authenticate is a middleware that lets through only requests with a valid access token or API key
+// in the Authorization header and puts their user or key into the context.
+// Requests made with an API key are logged with the key when they are served
+func (h *Handler) authenticate(ctx *gin.Context) {
+	const op = "handler.auth.authenticate"
+	scheme, credentials, _ := strings.Cut(ctx.GetHeader("Authorization"), " ")
+	credentials = strings.TrimSpace(credentials)
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && credentials != "":
+		user, err := h.authService.Authenticate(credentials)
+		if err != nil {
+			h.logger.Info("Request is not authenticated " + op + ": " + err.Error())
+			mlErr := errors.GetHTTPError(err)
+			ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			ctx.AbortWithStatusJSON(mlErr.Status, mlErr)
+			return
+		}
+		ctx.Set(userContextKey, user)
+		ctx.Next()
+
+	case strings.EqualFold(scheme, apiKeyScheme) && credentials != "":
+		key, err := h.apiKeyService.Authenticate(credentials)
+		if err != nil {
+			h.logger.Info("Request is not authenticated " + op + ": " + err.Error())
+			mlErr := errors.GetHTTPError(err)
+			ctx.Header("WWW-Authenticate", apiKeyScheme)
+			ctx.AbortWithStatusJSON(mlErr.Status, mlErr)
+			return
+		}
+		ctx.Set(apiKeyContextKey, key)
+		ctx.Next()
+		h.logger.Info("Request with API key served", slog.Int("apiKeyId", key.Id), slog.String("apiKey", key.Name),
+			slog.String("method", ctx.Request.Method), slog.String("path", ctx.FullPath()),
+			slog.Int("status", ctx.Writer.Status()))
+
+	default:
+		mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("no bearer token or api key"))
+		ctx.Header("WWW-Authenticate", "Bearer")
+		ctx.Writer.Header().Add("WWW-Authenticate", apiKeyScheme)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized,
+			errors.GetHTTPErrorWithMessage(mlErr, "Authorization header with a bearer token or an API key is required"))
+	}
+}
+
+// authorize returns a middleware that lets through only requests of users whose role has the permission
+// and of API keys that have it as a scope
+func (h *Handler) authorize(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		const op = "handler.auth.authorize"
+		if key, ok := currentApiKey(ctx); ok {
+			if !slices.Contains(key.Scopes, permission) {
+				mlErr := errors.NewMusicLibraryError(errors.ForbiddenError,
+					fmt.Errorf("api key %q has no %s scope", key.Name, permission))
+				h.logger.Info("Request is forbidden "+op+": "+mlErr.Error(), slog.Int("apiKeyId", key.Id))
+				ctx.AbortWithStatusJSON(http.StatusForbidden,
+					errors.GetHTTPErrorWithMessage(mlErr, permission+" scope is required"))
+				return
+			}
+			ctx.Next()
+			return
+		}
+		user, ok := currentUser(ctx)
+		if !ok || !h.policy.Allows(user.Role, permission) {
+			mlErr := errors.NewMusicLibraryError(errors.ForbiddenError,
+				fmt.Errorf("role %q has no %s permission", user.Role, permission))
+			h.logger.Info("Request is forbidden "+op+": "+mlErr.Error(), slog.Int("userId", user.Id))
+			ctx.AbortWithStatusJSON(http.StatusForbidden,
+				errors.GetHTTPErrorWithMessage(mlErr, permission+" permission is required"))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// currentUser returns the authenticated user of the request
+func currentUser(ctx *gin.Context) (models.User, bool) {
+	value, ok := ctx.Get(userContextKey)
+	if !ok {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
+
+// currentApiKey returns the API key the request is authenticated with
+func currentApiKey(ctx *gin.Context) (models.ApiKey, bool) {
+	value, ok := ctx.Get(apiKeyContextKey)
+	if !ok {
+		return models.ApiKey{}, false
+	}
+	key, ok := value.(models.ApiKey)
+	return key, ok
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// songETag returns the entity tag of the song version
+func songETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion returns the song version required by the If-Match header, 0 if any version is fine.
+// Only a single entity tag is accepted, a weak one is compared as a strong one
+func ifMatchVersion(ctx *gin.Context) (int, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, fmt.Errorf("only one entity tag is supported in If-Match")
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, fmt.Errorf("bad entity tag %s", header)
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("bad entity tag %s", header)
+	}
+	return version, nil
+}
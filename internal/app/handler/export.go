@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"log/slog"
+	"net/http"
+)
+
+// exportContentTypes maps the formats of an export to their content types
+var exportContentTypes = map[string]string{
+	"ndjson": "application/x-ndjson",
+	"csv":    "text/csv; charset=utf-8",
+	"json":   "application/json; charset=utf-8",
+}
+
+// Export Handler to export the whole library
+//
+//	@Summary		Export the library
+//	@Description	Streams every song with its groups and verses in order as an attachment, the output can be sent to /import as it is
+//	@Description	Supports the filters of /library(search, dateFrom, dateTo, albumId, fuzzy params), songs are ordered by id
+//	@Description	CSV has the columns group, song, releaseDate, link, text, groups and draft, verses are joined into text
+//	@Description	Songs without verses are marked as drafts, so that the import does not look up their metadata
+//	@Tags			library
+//	@Produce		application/x-ndjson
+//	@Produce		text/csv
+//	@Produce		json
+//	@Param			format				query		string	false	"format of the export"	Enums(ndjson, csv, json)	default(ndjson)
+//	@Param			search				query		string	false	"search query for filtering by song and group names"
+//	@Param			dateFrom			query		string	false	"the date from which the release dates of the songs begin"
+//	@Param			dateTo				query		string	false	"the date from which the release dates of the songs end"
+//	@Param			albumId				query		int		false	"id of the album whose tracks should be exported"
+//	@Param			fuzzy				query		bool	false	"typo-tolerant search by song and group names"
+//	@Success		200					{array}		models.ImportRow
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/export [get]
+func (h *Handler) Export(ctx *gin.Context) {
+	const op = "handler.export.Export"
+	format := ctx.DefaultQuery("format", "ndjson")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("unknown export format %q", format))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "format must be ndjson, csv or json"))
+		return
+	}
+	filter, message, err := libraryFilter(ctx)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, message))
+		return
+	}
+
+	h.logger.Info("Exporting library", slog.String("format", format))
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="library.%s"`, format))
+	err = h.libraryService.Export(format, filter, ctx.Writer)
+	if err != nil {
+		h.logger.Error("Error while exporting library " + op + ": " + err.Error())
+		if ctx.Writer.Written() {
+			// the status is already sent, the client sees a truncated export
+			ctx.Abort()
+			return
+		}
+		ctx.Header("Content-Disposition", "")
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Library exported", slog.String("format", format))
+}
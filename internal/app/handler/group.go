@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// GetGroups Handler to get a list of groups
+//
+//	@Summary		Get a list of groups
+//	@Description	Supports pagination(limit, page params)
+//	@Description	Supports filtration by group name(search param)
+//	@Tags			group
+//	@Produce		json
+//	@Param			limit				query		int		false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int		false	"page of data that you want to receive"	default(0)	example(2)
+//	@Param			search				query		string	false	"search query for filtering by group name"
+//	@Success		200					{object}	models.GroupsResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/group [get]
+func (h *Handler) GetGroups(ctx *gin.Context) {
+	const op = "handler.group.GetGroups"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+	search := ctx.Query("search")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting groups")
+
+	count, groups, err := h.groupService.GetGroups(limit, page, search)
+	if err != nil {
+		h.logger.Error("Error while getting groups " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got groups", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":  count,
+			"groups": groups,
+		},
+	})
+}
+
+// GetGroup Handler to get a certain group
+//
+//	@Summary	Get a certain group
+//	@Tags		group
+//	@Produce	json
+//	@Param		id						path		int	true	"id of the chosen group"
+//	@Success	200						{object}	models.GroupResponse
+//	@Failure	400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/group/{id} [get]
+func (h *Handler) GetGroup(ctx *gin.Context) {
+	const op = "handler.group.GetGroup"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting group", slog.Int("id", id))
+
+	group, err := h.groupService.GetGroup(id)
+	if err != nil {
+		h.logger.Error("Error while getting group " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got group", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: group,
+	})
+}
+
+// GetGroupSongs Handler to get the discography of a group
+//
+//	@Summary		Get the discography of a group
+//	@Description	Songs are ordered by release date
+//	@Tags			group
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen group"
+//	@Success		200						{object}	models.GroupSongsResponse
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/group/{id}/songs [get]
+func (h *Handler) GetGroupSongs(ctx *gin.Context) {
+	const op = "handler.group.GetGroupSongs"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting group songs", slog.Int("id", id))
+
+	count, songs, err := h.groupService.GetGroupSongs(id)
+	if err != nil {
+		h.logger.Error("Error while getting group songs " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got group songs", slog.Int("id", id), slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count": count,
+			"songs": songs,
+		},
+	})
+}
+
+// RenameGroup Handler to rename a group
+//
+//	@Summary		Rename a group
+//	@Description	Renaming to the name of another group is rejected, merge the groups instead
+//	@Tags			group
+//	@Accept			json
+//	@Produce		json
+//	@Param			id							path		int					true	"id of the chosen group"
+//	@Param			input						body		models.GroupRequest	true	"New name of the group"
+//	@Success		200							{object}	models.Response
+//	@Failure		400,401,403,404,409,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/group/{id} [put]
+func (h *Handler) RenameGroup(ctx *gin.Context) {
+	const op = "handler.group.RenameGroup"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.GroupRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Renaming group", slog.Int("id", id))
+
+	err = h.groupService.RenameGroup(id, input.Name, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while renaming group " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Group renamed", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// MergeGroups Handler to merge groups into a certain group
+//
+//	@Summary		Merge groups into a certain group
+//	@Description	Songs of the merged groups are moved to the chosen group, the merged groups are deleted
+//	@Tags			group
+//	@Accept			json
+//	@Produce		json
+//	@Param			id						path		int							true	"id of the group that remains"
+//	@Param			input					body		models.MergeGroupsRequest	true	"ids of the groups to be merged"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/group/{id}/merge [post]
+func (h *Handler) MergeGroups(ctx *gin.Context) {
+	const op = "handler.group.MergeGroups"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.MergeGroupsRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Merging groups", slog.Int("id", id), slog.Any("groupIds", input.GroupIds))
+
+	err = h.groupService.MergeGroups(id, input.GroupIds, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while merging groups " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Groups merged", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// DeleteGroup Handler to delete a group
+//
+//	@Summary		Delete a group
+//	@Description	Only groups without songs can be deleted
+//	@Tags			group
+//	@Produce		json
+//	@Param			id							path		int	true	"id of the chosen group"
+//	@Success		200							{object}	models.Response
+//	@Failure		400,401,403,404,409,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/group/{id} [delete]
+func (h *Handler) DeleteGroup(ctx *gin.Context) {
+	const op = "handler.group.DeleteGroup"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Deleting group", slog.Int("id", id))
+
+	err = h.groupService.DeleteGroup(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while deleting group " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Group deleted", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
@@ -1,48 +1,173 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	_ "github.com/nosikmy/music-library/docs"
 	"github.com/nosikmy/music-library/internal/app/models"
+	"github.com/nosikmy/music-library/internal/app/policy"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"io"
 	"log/slog"
 	"net/http"
 	"time"
 )
 
+type AuthService interface {
+	Register(username string, password string, actor models.Actor) (int, error)
+	Login(username string, password string) (models.Tokens, error)
+	Refresh(refreshToken string) (models.Tokens, error)
+	Authenticate(accessToken string) (models.User, error)
+}
+
+// Policy tells whether a role has a permission
+type Policy interface {
+	Allows(role string, permission string) bool
+}
+
+type UserService interface {
+	GetUsers(limit int, page int) (int, []models.User, error)
+	ChangeRole(id int, role string, actor models.Actor) error
+	DeleteUser(id int, actor models.Actor) error
+}
+
+type ApiKeyService interface {
+	CreateApiKey(name string, scopes []string, expiresAt *time.Time, createdBy int,
+		actor models.Actor) (int, string, error)
+	GetApiKeys(limit int, page int) (int, []models.ApiKey, error)
+	RevokeApiKey(id int, actor models.Actor) error
+	RotateApiKey(id int, actor models.Actor) (string, error)
+	Authenticate(key string) (models.ApiKey, error)
+}
+
 type LibraryService interface {
-	GetLibrary(limit int, page int,
-		searchText string, dateFrom, dateTo time.Time) (int, []models.Song, error)
+	GetLibrary(limit int, page int, cursor string, filter models.LibraryFilter) (int, []models.Song, string, error)
+	Search(limit int, page int, searchText string) (int, []models.SearchHit, error)
+	Export(format string, filter models.LibraryFilter, w io.Writer) error
 }
 
 type SongService interface {
-	GetSongText(id int, limit int, page int) (int, []models.Verse, error)
-	DeleteSong(id int) error
-	ChangeSong(id int, changeName string, newGroup string, deleteGroupId int,
-		newVerse *models.Verse, changeVerse *models.Verse, deleteVerseId int) error
-	AddSong(group string, song string, songData models.ApiMusicResponse) (int, error)
+	GetSongText(id int, limit int, page int, cursor string) (int, []models.Verse, string, error)
+	GetSong(id int, includeText bool) (models.Song, error)
+	GetSongVersion(id int) (int, error)
+	DeleteSong(id int, version int, actor models.Actor) error
+	ChangeSong(id int, actor models.Actor, version int, operations []models.SongOperation) (int, error)
+	PatchSong(id int, actor models.Actor, version int, patchType string, patch []byte) (int, error)
+	AddSong(ctx context.Context, group string, song string, actor models.Actor) (int, []models.Group, error)
+	GetSongLRC(id int) (string, string, error)
+	ImportLyrics(id int, version int, lrc string, actor models.Actor) (int, int, error)
+	GetHistory(id int, limit int, page int) (int, []models.Revision, error)
+	GetRevisionDiff(id int, revision int) ([]models.DiffLine, error)
+	RestoreRevision(id int, revision int, actor models.Actor, version int) (int, int, error)
+}
+
+type AlbumService interface {
+	GetAlbums(limit int, page int) (int, []models.Album, error)
+	GetAlbum(id int) (models.Album, error)
+	AddAlbum(name string, releaseDate time.Time, actor models.Actor) (int, error)
+	ChangeAlbum(id int, name string, releaseDate time.Time, actor models.Actor) error
+	DeleteAlbum(id int, actor models.Actor) error
+	AddTrack(albumId int, track models.AlbumTrack, actor models.Actor) error
+	DeleteTrack(albumId int, songId int, actor models.Actor) error
+}
+
+type GroupService interface {
+	GetGroups(limit int, page int, searchText string) (int, []models.Group, error)
+	GetGroup(id int) (models.Group, error)
+	GetGroupSongs(id int) (int, []models.Song, error)
+	RenameGroup(id int, newName string, actor models.Actor) error
+	MergeGroups(id int, mergedIds []int, actor models.Actor) error
+	DeleteGroup(id int, actor models.Actor) error
+}
+
+type PlaylistService interface {
+	GetPlaylists(limit int, page int) (int, []models.Playlist, error)
+	GetPlaylist(id int) (models.Playlist, error)
+	GetPlaylistItems(id int, limit int, page int) (int, []models.PlaylistItem, error)
+	AddPlaylist(name string, actor models.Actor) (int, error)
+	RenamePlaylist(id int, name string, actor models.Actor) error
+	DeletePlaylist(id int, actor models.Actor) error
+	AddItem(id int, songId int, position int, actor models.Actor) (int, error)
+	MoveItem(id int, itemId int, position int, actor models.Actor) error
+	DeleteItem(id int, itemId int, actor models.Actor) error
+	ExportPlaylist(id int, format string) ([]byte, error)
+}
+
+type TrashService interface {
+	GetTrash(limit int, page int) (int, []models.Song, error)
+	RestoreSong(id int, actor models.Actor) error
+	PurgeSong(id int, actor models.Actor) error
+}
+
+type JobService interface {
+	AddSongAsync(group string, song string, actor models.Actor) (int, int, error)
+	GetJob(id int) (models.Job, error)
+}
+
+type ImportService interface {
+	Import(format string, r io.Reader, actor models.Actor) (models.ImportReport, error)
+}
+
+type AuditService interface {
+	GetAuditEvents(filter models.AuditFilter, limit int, page int) (int, []models.AuditEvent, error)
 }
 
 type Handler struct {
-	logger         *slog.Logger
-	libraryService LibraryService
-	songService    SongService
+	logger          *slog.Logger
+	policy          Policy
+	ipLimiter       RateLimiter
+	readLimiter     RateLimiter
+	writeLimiter    RateLimiter
+	authService     AuthService
+	userService     UserService
+	apiKeyService   ApiKeyService
+	libraryService  LibraryService
+	songService     SongService
+	albumService    AlbumService
+	groupService    GroupService
+	playlistService PlaylistService
+	trashService    TrashService
+	jobService      JobService
+	importService   ImportService
+	auditService    AuditService
 }
 
-func NewHandler(logger *slog.Logger, l LibraryService, s SongService) *Handler {
+func NewHandler(logger *slog.Logger, pl Policy, ri RateLimiter, rr RateLimiter, rw RateLimiter, au AuthService,
+	u UserService, k ApiKeyService, l LibraryService, s SongService, a AlbumService, g GroupService,
+	p PlaylistService, t TrashService, j JobService, i ImportService, ad AuditService) *Handler {
 	return &Handler{
-		logger:         logger,
-		libraryService: l,
-		songService:    s,
+		logger:          logger,
+		policy:          pl,
+		ipLimiter:       ri,
+		readLimiter:     rr,
+		writeLimiter:    rw,
+		authService:     au,
+		userService:     u,
+		apiKeyService:   k,
+		libraryService:  l,
+		songService:     s,
+		albumService:    a,
+		groupService:    g,
+		playlistService: p,
+		trashService:    t,
+		jobService:      j,
+		importService:   i,
+		auditService:    ad,
 	}
 }
 
-func (h *Handler) InitRoutes() *gin.Engine {
+// InitRoutes builds the router. The client IP, which tells anonymous clients apart in the rate limits,
+// is taken from the X-Forwarded-For header only behind the trusted proxies, given as IPs or CIDRs
+func (h *Handler) InitRoutes(trustedProxies []string) (*gin.Engine, error) {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("bad trusted proxies: %w", err)
+	}
 
-	router.Use(gin.Recovery())
+	router.Use(gin.Recovery(), h.assignRequestId)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.GET("/swagger", func(c *gin.Context) {
@@ -50,18 +175,114 @@ func (h *Handler) InitRoutes() *gin.Engine {
 		c.Redirect(http.StatusMovedPermanently, "/swagger/index.html")
 	})
 
-	router.GET("/library", h.GetLibrary)
-	songRouter := router.Group("/song")
+	authRouter := router.Group("/auth", h.limitRate)
+	{
+		authRouter.POST("/register", h.Register)
+		authRouter.POST("/login", h.Login)
+		authRouter.POST("/refresh", h.Refresh)
+	}
+
+	apiRouter := router.Group("", h.limitIP, h.authenticate, h.limitRate)
+	libraryRouter := apiRouter.Group("", h.authorize(policy.LibraryRead))
 	{
-		songRouter.POST("", h.AddSong)
-		songRouterId := songRouter.Group("/:id")
+		libraryRouter.GET("/library", h.GetLibrary)
+		libraryRouter.GET("/search", h.Search)
+		libraryRouter.GET("/export", h.Export)
+		libraryRouter.GET("/jobs/:id", h.GetJob)
+	}
+	apiRouter.POST("/import", h.authorize(policy.SongWrite), h.ImportSongs)
+	songRouter := apiRouter.Group("/song")
+	{
+		songReadRouter := songRouter.Group("/:id", h.authorize(policy.LibraryRead))
 		{
-			songRouterId.GET("/text", h.GetSongText)
-			songRouterId.DELETE("", h.DeleteSong)
-			songRouterId.PUT("", h.ChangeSong)
+			songReadRouter.GET("", h.GetSong)
+			songReadRouter.GET("/text", h.GetSongText)
+			songReadRouter.GET("/history", h.GetHistory)
+			songReadRouter.GET("/history/:rev/diff", h.GetRevisionDiff)
 		}
-
+		songWriteRouter := songRouter.Group("", h.authorize(policy.SongWrite))
+		{
+			songWriteRouter.POST("", h.AddSong)
+			songWriteRouter.PUT("/:id", h.ChangeSong)
+			songWriteRouter.PATCH("/:id", h.PatchSong)
+			songWriteRouter.POST("/:id/lyrics", h.ImportLyrics)
+			songWriteRouter.POST("/:id/history/:rev/restore", h.RestoreRevision)
+		}
+		songDeleteRouter := songRouter.Group("/:id", h.authorize(policy.SongDelete))
+		{
+			songDeleteRouter.DELETE("", h.DeleteSong)
+			songDeleteRouter.POST("/restore", h.RestoreSong)
+		}
+	}
+	albumRouter := apiRouter.Group("/album")
+	{
+		albumReadRouter := albumRouter.Group("", h.authorize(policy.LibraryRead))
+		{
+			albumReadRouter.GET("", h.GetAlbums)
+			albumReadRouter.GET("/:id", h.GetAlbum)
+		}
+		albumWriteRouter := albumRouter.Group("", h.authorize(policy.AlbumWrite))
+		{
+			albumWriteRouter.POST("", h.AddAlbum)
+			albumWriteRouter.PUT("/:id", h.ChangeAlbum)
+			albumWriteRouter.DELETE("/:id", h.DeleteAlbum)
+			albumWriteRouter.POST("/:id/tracks", h.AddTrack)
+			albumWriteRouter.DELETE("/:id/tracks/:songId", h.DeleteTrack)
+		}
+	}
+	groupRouter := apiRouter.Group("/group")
+	{
+		groupReadRouter := groupRouter.Group("", h.authorize(policy.LibraryRead))
+		{
+			groupReadRouter.GET("", h.GetGroups)
+			groupReadRouter.GET("/:id", h.GetGroup)
+			groupReadRouter.GET("/:id/songs", h.GetGroupSongs)
+		}
+		groupManageRouter := groupRouter.Group("/:id", h.authorize(policy.GroupManage))
+		{
+			groupManageRouter.PUT("", h.RenameGroup)
+			groupManageRouter.DELETE("", h.DeleteGroup)
+			groupManageRouter.POST("/merge", h.MergeGroups)
+		}
+	}
+	playlistRouter := apiRouter.Group("/playlist")
+	{
+		playlistReadRouter := playlistRouter.Group("", h.authorize(policy.LibraryRead))
+		{
+			playlistReadRouter.GET("", h.GetPlaylists)
+			playlistReadRouter.GET("/:id", h.GetPlaylist)
+			playlistReadRouter.GET("/:id/items", h.GetPlaylistItems)
+			playlistReadRouter.GET("/:id/export", h.ExportPlaylist)
+		}
+		playlistWriteRouter := playlistRouter.Group("", h.authorize(policy.PlaylistWrite))
+		{
+			playlistWriteRouter.POST("", h.AddPlaylist)
+			playlistWriteRouter.PUT("/:id", h.RenamePlaylist)
+			playlistWriteRouter.DELETE("/:id", h.DeletePlaylist)
+			playlistWriteRouter.POST("/:id/items", h.AddPlaylistItem)
+			playlistWriteRouter.PUT("/:id/items/:itemId", h.MovePlaylistItem)
+			playlistWriteRouter.DELETE("/:id/items/:itemId", h.DeletePlaylistItem)
+		}
+	}
+	trashRouter := apiRouter.Group("/trash", h.authorize(policy.SongDelete))
+	{
+		trashRouter.GET("", h.GetTrash)
+		trashRouter.DELETE("/:id", h.PurgeSong)
+	}
+	userRouter := apiRouter.Group("/user", h.authorize(policy.UserManage))
+	{
+		userRouter.GET("", h.GetUsers)
+		userRouter.PUT("/:id/role", h.ChangeUserRole)
+		userRouter.DELETE("/:id", h.DeleteUser)
+	}
+	apiRouter.GET("/audit", h.authorize(policy.AuditRead), h.GetAuditEvents)
+	apiKeyRouter := apiRouter.Group("/apikey", h.authorize(policy.ApiKeyManage))
+	{
+		apiKeyRouter.POST("", h.CreateApiKey)
+		apiKeyRouter.GET("", h.GetApiKeys)
+		apiKeyRouter.DELETE("/:id", h.RevokeApiKey)
+		apiKeyRouter.POST("/:id/rotate", h.RotateApiKey)
 	}
 
-	return router
+	return router, nil
 }
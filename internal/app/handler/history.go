@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// anonymousEditor is recorded in the song history when the request has no user
+const anonymousEditor = "anonymous"
+
+// apiKeyEditorPrefix marks the changes made with an API key in the song history
+const apiKeyEditorPrefix = "apikey:"
+
+// GetHistory Handler to get the revision history of a song
+//
+//	@Summary		Get the revision history of a song
+//	@Description	Every change of a song is recorded with the editor, the time and the state before and after it
+//	@Description	Supports pagination(limit, page params), the latest revisions come first
+//	@Tags			history
+//	@Produce		json
+//	@Param			id					path		int	true	"id of the chosen song"
+//	@Param			limit				query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int	false	"page of data that you want to receive"	default(0)	example(1)
+//	@Success		200					{object}	models.HistoryResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id}/history [get]
+func (h *Handler) GetHistory(ctx *gin.Context) {
+	const op = "handler.history.GetHistory"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting song history", slog.Int("id", id))
+
+	count, revisions, err := h.songService.GetHistory(id, limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting song history " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got song history", slog.Int("id", id), slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":     count,
+			"revisions": revisions,
+		},
+	})
+}
+
+// GetRevisionDiff Handler to get the changes of the lyrics made by a revision
+//
+//	@Summary		Get the changes of the lyrics made by a revision
+//	@Description	Returns a line diff of the lyrics before and after the revision, verses are separated by empty lines
+//	@Tags			history
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen song"
+//	@Param			rev						path		int	true	"number of the revision"
+//	@Success		200						{object}	models.RevisionDiffResponse
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id}/history/{rev}/diff [get]
+func (h *Handler) GetRevisionDiff(ctx *gin.Context) {
+	const op = "handler.history.GetRevisionDiff"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	revision, err := strconv.Atoi(ctx.Param("rev"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "revision is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting revision diff", slog.Int("id", id), slog.Int("revision", revision))
+
+	diff, err := h.songService.GetRevisionDiff(id, revision)
+	if err != nil {
+		h.logger.Error("Error while getting revision diff " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got revision diff", slog.Int("id", id), slog.Int("revision", revision))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"diff": diff,
+		},
+	})
+}
+
+// RestoreRevision Handler to roll a song back to a revision
+//
+//	@Summary		Roll a song back to a revision
+//	@Description	The name, groups and verses of the song are brought back to their state right after the revision
+//	@Description	The restore is recorded as a new revision
+//	@Tags			history
+//	@Produce		json
+//	@Param			id							path		int		true	"id of the chosen song"
+//	@Param			rev							path		int		true	"number of the revision"
+//	@Param			If-Match					header		string	false	"ETag of the song version being rolled back"
+//	@Success		200							{object}	models.RestoreRevisionResponse
+//	@Header			200							{string}	ETag	"new version of the song"
+//	@Failure		400,401,403,404,412,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id}/history/{rev}/restore [post]
+func (h *Handler) RestoreRevision(ctx *gin.Context) {
+	const op = "handler.history.RestoreRevision"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	revision, err := strconv.Atoi(ctx.Param("rev"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "revision is not a number"))
+		return
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad If-Match header"))
+		return
+	}
+
+	h.logger.Info("Restoring song revision", slog.Int("id", id), slog.Int("revision", revision))
+
+	newRevision, newVersion, err := h.songService.RestoreRevision(id, revision, auditActor(ctx), version)
+	if err != nil {
+		h.logger.Error("Error while restoring song revision " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Song revision restored", slog.Int("id", id), slog.Int("revision", revision))
+
+	ctx.Header("ETag", songETag(newVersion))
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"revision": newRevision,
+		},
+	})
+}
+
+// editorName returns the name of the user making the request
+func editorName(ctx *gin.Context) string {
+	if user, ok := currentUser(ctx); ok {
+		return user.Username
+	}
+	if key, ok := currentApiKey(ctx); ok {
+		return apiKeyEditorPrefix + key.Name
+	}
+	return anonymousEditor
+}
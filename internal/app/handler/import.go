@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+)
+
+// importFormats maps the content types of an import to its formats
+var importFormats = map[string]string{
+	"text/csv":             "csv",
+	"application/x-ndjson": "ndjson",
+	"application/jsonl":    "ndjson",
+	"application/json":     "json",
+}
+
+// ImportSongs Handler to import songs in bulk
+//
+//	@Summary		Import songs in bulk
+//	@Description	Accepts CSV, JSON Lines or a JSON array as the request body or as the "file" field of a multipart form
+//	@Description	CSV needs a header naming the columns: group, song, releaseDate, link, text, groups and draft, only song is required
+//	@Description	Groups in a CSV cell are separated by new lines, JSON rows may have verses instead of text, the output of /export can be imported as it is
+//	@Description	Rows with text and drafts are added as they are, the others get their metadata from the music API in the background, see /jobs/{id}
+//	@Description	A song without groups is added without them, looking up its metadata needs a group
+//	@Description	A song already in the library is left unchanged, the outcome of every row is reported
+//	@Tags			song
+//	@Accept			text/csv
+//	@Accept			application/x-ndjson
+//	@Accept			application/json
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			format				query		string	false	"format of the rows, taken from the content type if not set"	Enums(csv, ndjson, json)
+//	@Param			file				formData	file	false	"file with the rows"
+//	@Success		200					{object}	models.ImportResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/import [post]
+func (h *Handler) ImportSongs(ctx *gin.Context) {
+	const op = "handler.import.ImportSongs"
+	format := ctx.Query("format")
+	if format == "" && ctx.ContentType() != gin.MIMEMultipartPOSTForm {
+		format = importFormats[ctx.ContentType()]
+	}
+	if format == "" {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("unknown import format"))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "format must be csv, ndjson or json"))
+		return
+	}
+	file, err := openUpload(ctx, "file")
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "failed to read the rows"))
+		return
+	}
+	defer file.Close()
+
+	h.logger.Info("Importing songs", slog.String("format", format))
+
+	report, err := h.importService.Import(format, file, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while importing songs " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Songs imported", slog.Int("created", report.Created), slog.Int("existing", report.Existing),
+		slog.Int("failed", report.Failed))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"report": report,
+		},
+	})
+}
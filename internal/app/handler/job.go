@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// GetJob Handler to get a background job
+//
+//	@Summary		Get a background job
+//	@Description	Status is pending, running, done or failed. A failed attempt is retried with backoff, lastError holds its error
+//	@Tags			job
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen job"
+//	@Success		200						{object}	models.JobResponse
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/jobs/{id} [get]
+func (h *Handler) GetJob(ctx *gin.Context) {
+	const op = "handler.job.GetJob"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting job", slog.Int("id", id))
+
+	job, err := h.jobService.GetJob(id)
+	if err != nil {
+		h.logger.Error("Error while getting job " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got job", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"job": job,
+		},
+	})
+}
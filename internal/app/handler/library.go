@@ -1,29 +1,40 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/nosikmy/music-library/internal/app/errors"
 	"github.com/nosikmy/music-library/internal/app/models"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // GetLibrary Handler to get a list of songs
 //
 //	@Summary		Get a list of songs
-//	@Description	Supports pagination(limit, page params)
-//	@Description	Supports filtration(search, dateFrom, dateTo params)
+//	@Description	Supports pagination(limit, page params), pages are made of distinct songs ordered by id by default
+//	@Description	Supports sorting(sort param)
+//	@Description	Supports keyset pagination(limit, cursor params), nextCursor is empty on the last page
+//	@Description	Supports filtration(search, dateFrom, dateTo, albumId params)
+//	@Description	count is the number of songs on the page, total is the number of songs matching the filters
 //	@Tags			library
 //	@Produce		json
-//	@Param			limit		query		int		false	"limit of received data"				default(10)	example(10)
-//	@Param			page		query		int		false	"page of data that you want to receive"	default(0)	example(2)
-//	@Param			search		query		string	false	"search query for filtering by song and group names"
-//	@Param			dateFrom	query		string	false	"the date from which the release dates of the songs begin"
-//	@Param			dateTo		query		string	false	"the date from which the release dates of the songs end"
-//	@Success		200			{object}	models.LibraryResponse
-//	@Failure		400,500		{object}	errors.MusicLibraryError
+//	@Param			limit				query		int		false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int		false	"page of data that you want to receive"	default(0)	example(2)
+//	@Param			cursor				query		string	false	"nextCursor of the previous page, page is ignored if it is set"
+//	@Param			search				query		string	false	"search query for filtering by song and group names"
+//	@Param			dateFrom			query		string	false	"the date from which the release dates of the songs begin"
+//	@Param			dateTo				query		string	false	"the date from which the release dates of the songs end"
+//	@Param			albumId				query		int		false	"id of the album whose tracks should be returned"
+//	@Param			fuzzy				query		bool	false	"typo-tolerant search by song and group names, results are ordered by similarity score"
+//	@Param			sort				query		string	false	"comma separated sort fields: id, name, releaseDate, groupName, score; prefix - for descending order"	example(-releaseDate,name)
+//	@Success		200					{object}	models.LibraryResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/library [get]
 func (h *Handler) GetLibrary(ctx *gin.Context) {
 	const op = "handler.library.GetLibrary"
@@ -35,9 +46,7 @@ func (h *Handler) GetLibrary(ctx *gin.Context) {
 	if pageStr == "" {
 		pageStr = "0"
 	}
-	search := ctx.Query("search")
-	dateFrom := ctx.Query("dateFrom")
-	dateTo := ctx.Query("dateTo")
+	cursor := ctx.Query("cursor")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
@@ -51,48 +60,159 @@ func (h *Handler) GetLibrary(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
 		return
 	}
+	filter, message, err := libraryFilter(ctx)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, message))
+		return
+	}
 
 	h.logger.Info("Getting library")
 
-	var dateFromTime time.Time
-	if dateFrom == "" {
-		dateFromTime = time.Time{}
-	} else {
-		dateFromTime, err = time.Parse("01.02.2006", dateFrom)
-		if err != nil {
-			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad date format"))
-			return
-		}
+	total, library, nextCursor, err := h.libraryService.GetLibrary(limit, page, cursor, filter)
+	if err != nil {
+		h.logger.Error("Error while getting library " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
 	}
-	var dateToTime time.Time
-	if dateTo == "" {
-		dateToTime = time.Time{}
-	} else {
-		dateToTime, err = time.Parse("01.02.2006", dateTo)
-		if err != nil {
-			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad date format"))
-			return
-		}
+
+	h.logger.Info("Got library", slog.Int("rowsCount", len(library)), slog.Int("total", total))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":      len(library),
+			"total":      total,
+			"library":    library,
+			"nextCursor": nextCursor,
+		},
+	})
+}
+
+// Search Handler to search songs by names, groups and lyrics
+//
+//	@Summary		Search songs by names, groups and lyrics
+//	@Description	Hits are ranked by relevance, the snippet highlights the best matching verse
+//	@Description	Supports pagination(limit, page params)
+//	@Tags			library
+//	@Produce		json
+//	@Param			q					query		string	true	"search query, supports quotes, OR and - operators"
+//	@Param			limit				query		int		false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int		false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200					{object}	models.SearchResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/search [get]
+func (h *Handler) Search(ctx *gin.Context) {
+	const op = "handler.library.Search"
+	searchText := ctx.Query("q")
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
 	}
-	count, library, err := h.libraryService.GetLibrary(limit, page, search, dateFromTime, dateToTime)
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	if searchText == "" {
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(errors.BadRequestError, "q is empty"))
+		return
+	}
+	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		h.logger.Error("Error while getting library " + op + ": " + err.Error())
-		ctx.JSON(http.StatusInternalServerError, errors.GetHTTPError(
-			errors.NewMusicLibraryError(errors.InternalError, err)),
-		)
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
 		return
 	}
 
-	h.logger.Info("Got library", slog.Int("rowsCount", count))
+	h.logger.Info("Searching songs", slog.String("q", searchText))
+
+	count, hits, err := h.libraryService.Search(limit, page, searchText)
+	if err != nil {
+		h.logger.Error("Error while searching songs " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Found songs", slog.Int("rowsCount", count))
 
 	ctx.JSON(http.StatusOK, models.Response{
 		Status:  http.StatusOK,
 		Message: "ok",
 		Payload: gin.H{
-			"count":   count,
-			"library": library,
+			"count": count,
+			"hits":  hits,
 		},
 	})
 }
+
+// libraryFilter builds the filter of the library from the query parameters.
+// On error, it also returns a message describing the bad parameter
+func libraryFilter(ctx *gin.Context) (models.LibraryFilter, string, error) {
+	filter := models.LibraryFilter{SearchText: ctx.Query("search")}
+
+	if albumIdStr := ctx.Query("albumId"); albumIdStr != "" {
+		albumId, err := strconv.Atoi(albumIdStr)
+		if err != nil {
+			return models.LibraryFilter{}, "albumId is not a number", err
+		}
+		filter.AlbumId = albumId
+	}
+
+	if fuzzyStr := ctx.Query("fuzzy"); fuzzyStr != "" {
+		fuzzy, err := strconv.ParseBool(fuzzyStr)
+		if err != nil {
+			return models.LibraryFilter{}, "fuzzy is not a boolean", err
+		}
+		filter.Fuzzy = fuzzy
+	}
+
+	sort, err := parseSortFields(ctx.Query("sort"))
+	if err != nil {
+		return models.LibraryFilter{}, "bad sort format", err
+	}
+	filter.Sort = sort
+
+	if dateFrom := ctx.Query("dateFrom"); dateFrom != "" {
+		filter.DateFrom, err = time.Parse("01.02.2006", dateFrom)
+		if err != nil {
+			return models.LibraryFilter{}, "bad date format", err
+		}
+	}
+	if dateTo := ctx.Query("dateTo"); dateTo != "" {
+		filter.DateTo, err = time.Parse("01.02.2006", dateTo)
+		if err != nil {
+			return models.LibraryFilter{}, "bad date format", err
+		}
+	}
+	return filter, "", nil
+}
+
+// parseSortFields parses a comma separated list of fields, each optionally prefixed with - for descending order
+func parseSortFields(sort string) ([]models.SortField, error) {
+	if sort == "" {
+		return nil, nil
+	}
+	var fields []models.SortField
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		if field == "" {
+			return nil, fmt.Errorf("empty sort field in %q", sort)
+		}
+		fields = append(fields, models.SortField{Field: field, Desc: desc})
+	}
+	return fields, nil
+}
@@ -0,0 +1,510 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// playlistContentTypes maps the formats of a playlist export to their content types
+var playlistContentTypes = map[string]string{
+	"m3u":  "audio/x-mpegurl; charset=utf-8",
+	"xspf": "application/xspf+xml; charset=utf-8",
+}
+
+// GetPlaylists Handler to get a list of playlists
+//
+//	@Summary		Get a list of playlists
+//	@Description	Supports pagination(limit, page params)
+//	@Tags			playlist
+//	@Produce		json
+//	@Param			limit				query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int	false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200					{object}	models.PlaylistsResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist [get]
+func (h *Handler) GetPlaylists(ctx *gin.Context) {
+	const op = "handler.playlist.GetPlaylists"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting playlists")
+
+	count, playlists, err := h.playlistService.GetPlaylists(limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting playlists " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got playlists", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":     count,
+			"playlists": playlists,
+		},
+	})
+}
+
+// GetPlaylist Handler to get a playlist
+//
+//	@Summary	Get a playlist with the number of its items
+//	@Tags		playlist
+//	@Produce	json
+//	@Param		id						path		int	true	"id of the chosen playlist"
+//	@Success	200						{object}	models.PlaylistResponse
+//	@Failure	400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/playlist/{id} [get]
+func (h *Handler) GetPlaylist(ctx *gin.Context) {
+	const op = "handler.playlist.GetPlaylist"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting playlist", slog.Int("id", id))
+
+	playlist, err := h.playlistService.GetPlaylist(id)
+	if err != nil {
+		h.logger.Error("Error while getting playlist " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got playlist", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: playlist,
+	})
+}
+
+// AddPlaylist Handler to add a playlist
+//
+//	@Summary	Add a playlist
+//	@Tags		playlist
+//	@Accept		json
+//	@Produce	json
+//	@Param		input				body		models.PlaylistRequest	true	"Data for adding a playlist"
+//	@Success	200					{object}	models.AddPlaylistResponse
+//	@Failure	400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/playlist [post]
+func (h *Handler) AddPlaylist(ctx *gin.Context) {
+	const op = "handler.playlist.AddPlaylist"
+	var input models.PlaylistRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Adding new playlist", slog.String("name", input.Name))
+
+	id, err := h.playlistService.AddPlaylist(input.Name, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while adding playlist " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("New playlist added", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"id": id,
+		},
+	})
+}
+
+// RenamePlaylist Handler to rename a playlist
+//
+//	@Summary	Rename a playlist
+//	@Tags		playlist
+//	@Accept		json
+//	@Produce	json
+//	@Param		id						path		int						true	"id of the chosen playlist"
+//	@Param		input					body		models.PlaylistRequest	true	"New name of the playlist"
+//	@Success	200						{object}	models.Response
+//	@Failure	400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Router		/playlist/{id} [put]
+func (h *Handler) RenamePlaylist(ctx *gin.Context) {
+	const op = "handler.playlist.RenamePlaylist"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.PlaylistRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Renaming playlist", slog.Int("id", id))
+
+	err = h.playlistService.RenamePlaylist(id, input.Name, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while renaming playlist " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Playlist renamed", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// DeletePlaylist Handler to delete a playlist
+//
+//	@Summary		Delete a playlist
+//	@Description	Songs of the playlist stay in the library
+//	@Tags			playlist
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen playlist"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist/{id} [delete]
+func (h *Handler) DeletePlaylist(ctx *gin.Context) {
+	const op = "handler.playlist.DeletePlaylist"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Deleting playlist", slog.Int("id", id))
+
+	err = h.playlistService.DeletePlaylist(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while deleting playlist " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Playlist deleted", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// GetPlaylistItems Handler to get the songs of a playlist
+//
+//	@Summary		Get the songs of a playlist in order
+//	@Description	Supports pagination(limit, page params), songs are in the format of /library
+//	@Tags			playlist
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen playlist"
+//	@Param			limit					query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page					query		int	false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200						{object}	models.PlaylistItemsResponse
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist/{id}/items [get]
+func (h *Handler) GetPlaylistItems(ctx *gin.Context) {
+	const op = "handler.playlist.GetPlaylistItems"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting playlist items", slog.Int("id", id))
+
+	count, items, err := h.playlistService.GetPlaylistItems(id, limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting playlist items " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got playlist items", slog.Int("id", id), slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count": count,
+			"items": items,
+		},
+	})
+}
+
+// AddPlaylistItem Handler to add a song to a playlist
+//
+//	@Summary		Add a song to a playlist
+//	@Description	The song is put at the position, the songs from there on move down
+//	@Description	Without a position or with a position past the end the song is appended, a song may be added more than once
+//	@Tags			playlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			id						path		int							true	"id of the chosen playlist"
+//	@Param			input					body		models.PlaylistItemRequest	true	"Song and its position in the playlist"
+//	@Success		200						{object}	models.AddPlaylistItemResponse
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist/{id}/items [post]
+func (h *Handler) AddPlaylistItem(ctx *gin.Context) {
+	const op = "handler.playlist.AddPlaylistItem"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.PlaylistItemRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Adding song to playlist", slog.Int("id", id), slog.Int("songId", input.SongId))
+
+	itemId, err := h.playlistService.AddItem(id, input.SongId, input.Position, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while adding song to playlist " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Song added to playlist", slog.Int("id", id), slog.Int("itemId", itemId))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"itemId": itemId,
+		},
+	})
+}
+
+// MovePlaylistItem Handler to move a song of a playlist
+//
+//	@Summary		Move a song of a playlist to a position
+//	@Description	The songs between the old and the new position shift by one, a position past the end moves the song to the end
+//	@Tags			playlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			id						path		int								true	"id of the chosen playlist"
+//	@Param			itemId					path		int								true	"id of the item to be moved"
+//	@Param			input					body		models.MovePlaylistItemRequest	true	"New position of the item"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist/{id}/items/{itemId} [put]
+func (h *Handler) MovePlaylistItem(ctx *gin.Context) {
+	const op = "handler.playlist.MovePlaylistItem"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	itemId, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "itemId is not a number"))
+		return
+	}
+	var input models.MovePlaylistItemRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+
+	h.logger.Info("Moving playlist item", slog.Int("id", id), slog.Int("itemId", itemId),
+		slog.Int("position", input.Position))
+
+	err = h.playlistService.MoveItem(id, itemId, input.Position, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while moving playlist item " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Playlist item moved", slog.Int("id", id), slog.Int("itemId", itemId))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// DeletePlaylistItem Handler to remove a song from a playlist
+//
+//	@Summary		Remove a song from a playlist
+//	@Description	The songs after it move up
+//	@Tags			playlist
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen playlist"
+//	@Param			itemId					path		int	true	"id of the item to be removed"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist/{id}/items/{itemId} [delete]
+func (h *Handler) DeletePlaylistItem(ctx *gin.Context) {
+	const op = "handler.playlist.DeletePlaylistItem"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	itemId, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "itemId is not a number"))
+		return
+	}
+
+	h.logger.Info("Deleting playlist item", slog.Int("id", id), slog.Int("itemId", itemId))
+
+	err = h.playlistService.DeleteItem(id, itemId, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while deleting playlist item " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Playlist item deleted", slog.Int("id", id), slog.Int("itemId", itemId))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// ExportPlaylist Handler to export a playlist
+//
+//	@Summary		Export a playlist as an M3U or XSPF file
+//	@Description	Entries point to the links of the songs, songs without a link are left out of M3U
+//	@Tags			playlist
+//	@Produce		audio/x-mpegurl
+//	@Produce		application/xspf+xml
+//	@Param			id						path		int		true	"id of the chosen playlist"
+//	@Param			format					query		string	false	"format of the file"	Enums(m3u, xspf)	default(m3u)
+//	@Success		200						{string}	string	"playlist file"
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/playlist/{id}/export [get]
+func (h *Handler) ExportPlaylist(ctx *gin.Context) {
+	const op = "handler.playlist.ExportPlaylist"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	format := ctx.DefaultQuery("format", "m3u")
+	contentType, ok := playlistContentTypes[format]
+	if !ok {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("unknown playlist format %q", format))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "format must be m3u or xspf"))
+		return
+	}
+
+	h.logger.Info("Exporting playlist", slog.Int("id", id), slog.String("format", format))
+
+	file, err := h.playlistService.ExportPlaylist(id, format)
+	if err != nil {
+		h.logger.Error("Error while exporting playlist " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Playlist exported", slog.Int("id", id), slog.String("format", format))
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, id, format))
+	ctx.Data(http.StatusOK, contentType, file)
+}
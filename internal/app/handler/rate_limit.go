@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/ratelimit"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimiter gives every client a budget of requests
+type RateLimiter interface {
+	Allow(key string) ratelimit.Result
+}
+
+// limitRate is a middleware that spends the read budget of the client on GET requests and the write budget
+// on the others. Clients are told by their API key or user, the others by their IP
+func (h *Handler) limitRate(ctx *gin.Context) {
+	limiter := h.writeLimiter
+	if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+		limiter = h.readLimiter
+	}
+	h.spend(ctx, limiter, rateLimitKey(ctx))
+}
+
+// limitIP is a middleware that spends the budget of the IP of the request. It goes before authenticate,
+// so that requests with bad tokens or keys are limited as well
+func (h *Handler) limitIP(ctx *gin.Context) {
+	h.spend(ctx, h.ipLimiter, "ip:"+ctx.ClientIP())
+}
+
+// spend takes a request from the budget of the client and aborts with 429 if it is spent
+func (h *Handler) spend(ctx *gin.Context, limiter RateLimiter, client string) {
+	const op = "handler.rate_limit.spend"
+	result := limiter.Allow(client)
+	ctx.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	ctx.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		mlErr := errors.NewMusicLibraryError(errors.TooManyRequestsError,
+			fmt.Errorf("client %s is out of requests", client))
+		h.logger.Info("Request is rate limited "+op+": "+mlErr.Error(), slog.String("path", ctx.FullPath()))
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests,
+			errors.GetHTTPErrorWithMessage(mlErr, fmt.Sprintf("rate limit is exceeded, retry in %d seconds", retryAfter)))
+		return
+	}
+	ctx.Next()
+}
+
+// rateLimitKey returns the identity of the client of the request
+func rateLimitKey(ctx *gin.Context) string {
+	if key, ok := currentApiKey(ctx); ok {
+		return "apikey:" + strconv.Itoa(key.Id)
+	}
+	if user, ok := currentUser(ctx); ok {
+		return "user:" + strconv.Itoa(user.Id)
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// ceilSeconds rounds the duration up to whole seconds, as the headers are in seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
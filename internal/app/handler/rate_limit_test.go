@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"github.com/nosikmy/music-library/internal/app/ratelimit"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// spentLimiter denies every request and counts the requests of every client
+type spentLimiter struct {
+	calls map[string]int
+}
+
+func (l *spentLimiter) Allow(key string) ratelimit.Result {
+	l.calls[key]++
+	return ratelimit.Result{Limit: 1, RetryAfter: 1500 * time.Millisecond, Reset: time.Minute}
+}
+
+func TestLimitIPBeforeAuthentication(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		remoteAddr    string
+		forwardedFor  string
+		wantStatus    int
+		wantClient    string
+	}{
+		{
+			name:          "bad bearer token",
+			authorization: "Bearer not-a-token",
+			remoteAddr:    "203.0.113.7:4242",
+			wantStatus:    http.StatusTooManyRequests,
+			wantClient:    "ip:203.0.113.7",
+		},
+		{
+			name:          "api key",
+			authorization: "ApiKey 12.guess",
+			remoteAddr:    "203.0.113.7:4242",
+			wantStatus:    http.StatusTooManyRequests,
+			wantClient:    "ip:203.0.113.7",
+		},
+		{
+			name:       "no credentials",
+			remoteAddr: "203.0.113.7:4242",
+			wantStatus: http.StatusTooManyRequests,
+			wantClient: "ip:203.0.113.7",
+		},
+		{
+			name:         "untrusted forwarded for",
+			remoteAddr:   "203.0.113.7:4242",
+			forwardedFor: "198.51.100.1",
+			wantStatus:   http.StatusTooManyRequests,
+			wantClient:   "ip:203.0.113.7",
+		},
+		{
+			name:         "forwarded for of a trusted proxy",
+			remoteAddr:   "10.0.0.2:4242",
+			forwardedFor: "198.51.100.1",
+			wantStatus:   http.StatusTooManyRequests,
+			wantClient:   "ip:198.51.100.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ipLimiter := &spentLimiter{calls: map[string]int{}}
+			// the services are nil, so a request reaching authenticate would panic
+			h := &Handler{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), ipLimiter: ipLimiter}
+			router, err := h.InitRoutes([]string{"10.0.0.0/8"})
+			if err != nil {
+				t.Fatalf("InitRoutes() error = %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/library", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ipLimiter.calls[tt.wantClient] != 1 {
+				t.Errorf("limiter calls = %v, want one of %s", ipLimiter.calls, tt.wantClient)
+			}
+			if got := rec.Header().Get("Retry-After"); got != "2" {
+				t.Errorf("Retry-After = %q, want %q", got, "2")
+			}
+		})
+	}
+}
@@ -1,32 +1,94 @@
 package handler
 
 import (
-	"context"
-	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/go-resty/resty/v2"
-	"github.com/joho/godotenv"
 	"github.com/nosikmy/music-library/internal/app/errors"
 	"github.com/nosikmy/music-library/internal/app/models"
-	"github.com/sirupsen/logrus"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
-	"os"
 	"strconv"
-	"time"
 )
 
+// GetSong Handler to get a certain song
+//
+//	@Summary		Get a certain song
+//	@Description	Returns the groups, link, release date, number of verses and timestamps of the song
+//	@Description	With include=text the verses are embedded in order
+//	@Description	The ETag header holds the version of the song for If-Match of the song edits
+//	@Tags			song
+//	@Produce		json
+//	@Param			id						path		int		true	"id of the chosen song"
+//	@Param			include					query		string	false	"parts of the song to embed"	Enums(text)
+//	@Success		200						{object}	models.SongResponse
+//	@Header			200						{string}	ETag	"version of the song"
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id} [get]
+func (h *Handler) GetSong(ctx *gin.Context) {
+	const op = "handler.song.GetSong"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var includeText bool
+	switch ctx.Query("include") {
+	case "":
+	case "text":
+		includeText = true
+	default:
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("unknown include %q", ctx.Query("include")))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "include must be text"))
+		return
+	}
+
+	h.logger.Info("Getting song", slog.Int("id", id))
+
+	song, err := h.songService.GetSong(id, includeText)
+	if err != nil {
+		h.logger.Error("Error while getting song " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got song", slog.Int("id", id))
+
+	ctx.Header("ETag", songETag(song.Version))
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"song": song,
+		},
+	})
+}
+
 // GetSongText Handler to get the verses for a certain song
 //
 //	@Summary		Get the verses for a certain song
 //	@Description	Supports pagination(limit, page params)
+//	@Description	Supports keyset pagination(limit, cursor params), nextCursor is empty on the last page
+//	@Description	With format=lrc the whole time-synced lyrics are returned as an .lrc file, pagination is ignored
+//	@Description	The ETag header holds the version of the song for If-Match of the song edits
 //	@Tags			song
 //	@Produce		json
-//	@Param			id		path		int	true	"id of the chosen song"
-//	@Param			limit	query		int	false	"limit of received data"				default(2)	example(2)
-//	@Param			page	query		int	false	"page of data that you want to receive"	default(0)	example(1)
-//	@Success		200		{object}	models.SongTextResponse
-//	@Failure		400,500	{object}	errors.MusicLibraryError
+//	@Produce		plain
+//	@Param			id						path		int		true	"id of the chosen song"
+//	@Param			format					query		string	false	"format of the response"				Enums(json, lrc)	default(json)
+//	@Param			limit					query		int		false	"limit of received data"				default(2)			example(2)
+//	@Param			page					query		int		false	"page of data that you want to receive"	default(0)			example(1)
+//	@Param			cursor					query		string	false	"nextCursor of the previous page, page is ignored if it is set"
+//	@Success		200						{object}	models.SongTextResponse
+//	@Header			200						{string}	ETag	"version of the song"
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
 //	@Router			/song/{id}/text [get]
 func (h *Handler) GetSongText(ctx *gin.Context) {
 	const op = "handler.song.GetSongText"
@@ -39,6 +101,7 @@ func (h *Handler) GetSongText(ctx *gin.Context) {
 	if pageStr == "" {
 		pageStr = "0"
 	}
+	cursor := ctx.Query("cursor")
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -46,6 +109,25 @@ func (h *Handler) GetSongText(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
 		return
 	}
+	// the version is read first, so the text is never older than its ETag
+	version, err := h.songService.GetSongVersion(id)
+	if err != nil {
+		h.logger.Error("Error while getting song version " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+	ctx.Header("ETag", songETag(version))
+	switch ctx.Query("format") {
+	case "", "json":
+	case "lrc":
+		h.getSongLRC(ctx, id)
+		return
+	default:
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("unknown format %q", ctx.Query("format")))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "format must be json or lrc"))
+		return
+	}
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
 		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
@@ -61,35 +143,142 @@ func (h *Handler) GetSongText(ctx *gin.Context) {
 
 	h.logger.Info("Getting song text", slog.Int("id", id))
 
-	count, song, err := h.songService.GetSongText(id, limit, page)
+	count, song, nextCursor, err := h.songService.GetSongText(id, limit, page, cursor)
 	if err != nil {
 		h.logger.Error("Error while getting song text " + op + ": " + err.Error())
-		ctx.JSON(http.StatusInternalServerError, errors.GetHTTPError(
-			errors.NewMusicLibraryError(errors.InternalError, err)),
-		)
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
 	}
 
 	h.logger.Info("Got song text", slog.Int("id", id))
 
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count":      count,
+			"text":       song,
+			"nextCursor": nextCursor,
+		},
+	})
+}
+
+func (h *Handler) getSongLRC(ctx *gin.Context, id int) {
+	const op = "handler.song.getSongLRC"
+	h.logger.Info("Getting song lyrics in LRC format", slog.Int("id", id))
+
+	name, lrc, err := h.songService.GetSongLRC(id)
+	if err != nil {
+		h.logger.Error("Error while getting song lyrics " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got song lyrics in LRC format", slog.Int("id", id))
+
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".lrc"}))
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(lrc))
+}
+
+// ImportLyrics Handler to replace the lyrics of a song with time-synced lyrics
+//
+//	@Summary		Replace the lyrics of a song with time-synced lyrics
+//	@Description	Accepts an LRC file as the request body or as the "file" field of a multipart form
+//	@Description	Blank lines separate verses, a timed line without text ends a verse, repeated verses are stored as choruses
+//	@Tags			song
+//	@Accept			plain
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			id							path		int		true	"id of the chosen song"
+//	@Param			If-Match					header		string	false	"ETag of the song version being replaced"
+//	@Param			file						formData	file	false	"LRC file"
+//	@Success		200							{object}	models.ImportLyricsResponse
+//	@Header			200							{string}	ETag	"new version of the song"
+//	@Failure		400,401,403,404,412,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id}/lyrics [post]
+func (h *Handler) ImportLyrics(ctx *gin.Context) {
+	const op = "handler.song.ImportLyrics"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad If-Match header"))
+		return
+	}
+	lrc, err := readUpload(ctx, "file")
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "failed to read the lyrics"))
+		return
+	}
+
+	h.logger.Info("Importing song lyrics", slog.Int("id", id))
+
+	count, newVersion, err := h.songService.ImportLyrics(id, version, string(lrc), auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while importing song lyrics " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Song lyrics imported", slog.Int("id", id), slog.Int("versesCount", count))
+
+	ctx.Header("ETag", songETag(newVersion))
 	ctx.JSON(http.StatusOK, models.Response{
 		Status:  http.StatusOK,
 		Message: "ok",
 		Payload: gin.H{
 			"count": count,
-			"text":  song,
 		},
 	})
 }
 
+// readUpload reads the file of the multipart form field or the request body if the request is not a multipart form
+func readUpload(ctx *gin.Context, field string) ([]byte, error) {
+	file, err := openUpload(ctx, field)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// openUpload opens the file of the multipart form field or the request body if the request is not a multipart form
+func openUpload(ctx *gin.Context, field string) (io.ReadCloser, error) {
+	if ctx.ContentType() != gin.MIMEMultipartPOSTForm {
+		return ctx.Request.Body, nil
+	}
+	fileHeader, err := ctx.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	return fileHeader.Open()
+}
+
 // DeleteSong Handler to delete a certain song
 //
-//	@Summary	Delete a certain song
-//	@Tags		song
-//	@Produce	json
-//	@Param		id		path		int	true	"id of the chosen song"
-//	@Success	200		{object}	models.Response
-//	@Failure	400,500	{object}	errors.MusicLibraryError
-//	@Router		/song [delete]
+//	@Summary		Delete a certain song
+//	@Description	The song is moved to the trash, from where it can be restored or purged
+//	@Description	The song is removed from all playlists, restoring it does not put it back
+//	@Tags			song
+//	@Produce		json
+//	@Param			id							path		int		true	"id of the chosen song"
+//	@Param			If-Match					header		string	false	"ETag of the song version being deleted"
+//	@Success		200							{object}	models.Response
+//	@Failure		400,401,403,404,412,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id} [delete]
 func (h *Handler) DeleteSong(ctx *gin.Context) {
 	const op = "handler.song.DeleteSong"
 	idStr := ctx.Param("id")
@@ -100,15 +289,20 @@ func (h *Handler) DeleteSong(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
 		return
 	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad If-Match header"))
+		return
+	}
 
 	h.logger.Info("Deleting song", slog.Int("id", id))
 
-	err = h.songService.DeleteSong(id)
+	err = h.songService.DeleteSong(id, version, auditActor(ctx))
 	if err != nil {
-		h.logger.Error("Error while deleting text " + op + ": " + err.Error())
-		ctx.JSON(http.StatusInternalServerError, errors.GetHTTPError(
-			errors.NewMusicLibraryError(errors.InternalError, err)),
-		)
+		h.logger.Error("Error while deleting song " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
 		return
 	}
 
@@ -124,103 +318,83 @@ func (h *Handler) DeleteSong(ctx *gin.Context) {
 // ChangeSong Handler to change all fields of a song
 //
 //	@Summary		change all fields of a song
-//	@Description	Fields will be changed if the required parameters for this are specified
+//	@Description	Applies the operations of the request body in order, either all of them or none
+//	@Description	Inserted verses go after afterVerseId, 0 - to insert at the beginning, verses inserted after the same verse keep their order
+//	@Description	Empty text, kind and label of an updated verse are left unchanged, repeatOf = 0 stops the verse repeating another one
+//	@Description	Without a JSON body the query parameters are used, each of them adds one operation
 //	@Tags			song
+//	@Accept			json
 //	@Produce		json
-//	@Param			id				path		int		true	"id of the chosen song"
-//	@Param			name			query		string	false	"new name for song"
-//	@Param			newGroup		query		string	false	"new group name to add to the song"
-//	@Param			groupToDelete	query		string	false	"id of the group to be deleted from the song"
-//	@Param			newVersePrevId	query		string	false	"verse id, after which a new verse should be inserted. id = 0 - for insertion at the beginning"
-//	@Param			newVerseText	query		string	false	"text for a new verse"
-//	@Param			verseId			query		string	false	"id of the verse whose text must be changed"
-//	@Param			verseText		query		string	false	"new text for a verse"
-//	@Param			deleteVerseId	query		string	false	"id of the verse to be deleted"
-//	@Success		200				{object}	models.Response
-//	@Failure		400,500			{object}	errors.MusicLibraryError
-//	@Router			/song [put]
+//	@Param			id							path		int							true	"id of the chosen song"
+//	@Param			If-Match					header		string						false	"ETag of the song version being changed"
+//	@Param			input						body		models.SongUpdateRequest	false	"operations to apply"
+//	@Param			name						query		string						false	"new name for song"
+//	@Param			newGroup					query		string						false	"new group name to add to the song"
+//	@Param			groupToDelete				query		string						false	"id of the group to be deleted from the song"
+//	@Param			newVersePrevId				query		string						false	"verse id, after which a new verse should be inserted. id = 0 - for insertion at the beginning"
+//	@Param			newVerseText				query		string						false	"text for a new verse"
+//	@Param			newVerseKind				query		string						false	"kind of a new verse: intro, verse, chorus, bridge, outro or other"
+//	@Param			newVerseLabel				query		string						false	"label of a new verse"
+//	@Param			newVerseRepeatOf			query		string						false	"id of the verse that a new verse repeats, the text of a new verse is ignored"
+//	@Param			verseId						query		string						false	"id of the verse that must be changed"
+//	@Param			verseText					query		string						false	"new text for a verse, stops the verse repeating another one"
+//	@Param			verseKind					query		string						false	"new kind for a verse"
+//	@Param			verseLabel					query		string						false	"new label for a verse"
+//	@Param			verseRepeatOf				query		string						false	"id of the verse that a verse must repeat, 0 - to stop repeating"
+//	@Param			deleteVerseId				query		string						false	"id of the verse to be deleted"
+//	@Success		200							{object}	models.Response
+//	@Header			200							{string}	ETag	"new version of the song"
+//	@Failure		400,401,403,404,412,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id} [put]
 func (h *Handler) ChangeSong(ctx *gin.Context) {
 	const op = "handler.song.ChangeSong"
-	idStr := ctx.Param("id")
-	newName := ctx.Query("name")
-	newGroup := ctx.Query("newGroup")
-	deleteGroupIdStr := ctx.Query("groupToDelete")
-	newVerseIdPrevStr := ctx.Query("newVersePrevId")
-	newVerseText := ctx.Query("newVerseText")
-	changeVerseIdStr := ctx.Query("verseId")
-	changeVerseText := ctx.Query("verseText")
-	deleteVerseIdStr := ctx.Query("deleteVerseId")
-
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
 		return
 	}
-
-	var deleteGroupId int
-	if deleteGroupIdStr != "" {
-		deleteGroupId, err = strconv.Atoi(deleteGroupIdStr)
-		if err != nil {
-			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-			ctx.JSON(
-				http.StatusBadRequest, errors.GetHTTPErrorWithMessage(
-					mlErr, "id for deleting group is not a number"))
-			return
-		}
-	}
-	var deleteVerseId int
-	if deleteVerseIdStr != "" {
-		deleteVerseId, err = strconv.Atoi(deleteVerseIdStr)
-		if err != nil {
-			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(
-				mlErr, "id for deleting verse is not a number"))
-			return
-		}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad If-Match header"))
+		return
 	}
 
-	var newVerse *models.Verse
-	if newVerseIdPrevStr != "" {
-		newVerseIdPrev, err := strconv.Atoi(newVerseIdPrevStr)
-		if err != nil {
+	var operations []models.SongOperation
+	if ctx.ContentType() == gin.MIMEJSON {
+		var input models.SongUpdateRequest
+		if err = ctx.ShouldBindJSON(&input); err != nil {
 			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(
-				mlErr, "id of previous verse is not a number"))
+			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
 			return
 		}
-		newVerse = &models.Verse{
-			Id:   newVerseIdPrev,
-			Text: newVerseText,
-		}
-	}
-
-	var changeVerse *models.Verse
-	if changeVerseIdStr != "" {
-		changeVerseId, err := strconv.Atoi(changeVerseIdStr)
+		operations = input.Operations
+	} else {
+		var message string
+		operations, message, err = queryOperations(ctx)
 		if err != nil {
 			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(
-				mlErr, "id of verse for changing is not a number"))
+			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, message))
 			return
 		}
-		changeVerse = &models.Verse{
-			Id:   changeVerseId,
-			Text: changeVerseText,
-		}
 	}
 
 	h.logger.Info("Changing song", slog.Int("id", id))
 
-	err = h.songService.ChangeSong(id, newName, newGroup, deleteGroupId, newVerse, changeVerse, deleteVerseId)
+	newVersion, err := h.songService.ChangeSong(id, auditActor(ctx), version, operations)
 	if err != nil {
 		h.logger.Error("Error while changing song " + op + ": " + err.Error())
-		ctx.JSON(http.StatusInternalServerError, errors.GetHTTPError(
-			errors.NewMusicLibraryError(errors.InternalError, err)),
-		)
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
 		return
 	}
 
 	h.logger.Info("Song changed", slog.Int("id", id))
 
+	ctx.Header("ETag", songETag(newVersion))
 	ctx.JSON(http.StatusOK, models.Response{
 		Status:  http.StatusOK,
 		Message: "ok",
@@ -228,59 +402,180 @@ func (h *Handler) ChangeSong(ctx *gin.Context) {
 	})
 }
 
-// AddSong Handler to add a song to the library
+// PatchSong Handler to patch a song
 //
-//	@Summary	Add a song to the library
-//	@Tags		song
-//	@Produce	json
-//	@Param		input	body		models.ApiMusicRequest	true	"Data for adding a song"
-//	@Success	200		{object}	models.AddSongResponse
-//	@Failure	400,500	{object}	errors.MusicLibraryError
-//	@Router		/song [post]
-func (h *Handler) AddSong(ctx *gin.Context) {
-	const op = "handler.song.AddSong"
-	if err := godotenv.Load(); err != nil {
-		logrus.Fatalf("Error while loading .env file: %s", err.Error())
+//	@Summary		Patch a song
+//	@Description	Accepts a JSON Patch (application/json-patch+json) or a JSON Merge Patch (application/merge-patch+json) of the song document
+//	@Description	Verses and groups are matched by id, the ones without id are added. Ids and timings of verses are read-only, a release date can be changed but not cleared
+//	@Description	The changes are applied atomically, a failed test operation gives 409
+//	@Tags			song
+//	@Accept			application/json-patch+json
+//	@Accept			application/merge-patch+json
+//	@Produce		json
+//	@Param			id								path		int					true	"id of the chosen song"
+//	@Param			If-Match						header		string				false	"ETag of the song version being patched"
+//	@Param			input							body		models.SongDocument	true	"patch of the song document"
+//	@Success		200								{object}	models.Response
+//	@Header			200								{string}	ETag	"new version of the song"
+//	@Failure		400,401,403,404,409,412,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id} [patch]
+func (h *Handler) PatchSong(ctx *gin.Context) {
+	const op = "handler.song.PatchSong"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
 	}
-	//group := ctx.Query("group")
-	//name := ctx.Query("song")
-	var input models.ApiMusicRequest
-	if err := ctx.ShouldBindJSON(&input); err != nil {
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
 		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
-		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad If-Match header"))
+		return
+	}
+	patch, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "failed to read the patch"))
 		return
 	}
 
-	client := resty.New()
+	h.logger.Info("Patching song", slog.Int("id", id), slog.String("patchType", ctx.ContentType()))
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
+	newVersion, err := h.songService.PatchSong(id, auditActor(ctx), version, ctx.ContentType(), patch)
+	if err != nil {
+		h.logger.Error("Error while patching song " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
 
-	request := client.R().SetContext(ctxTimeout).SetQueryParams(map[string]string{
-		"group": input.Group,
-		"song":  input.Song,
+	h.logger.Info("Song patched", slog.Int("id", id))
+
+	ctx.Header("ETag", songETag(newVersion))
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
 	})
-	resp, err := request.Get(os.Getenv("API_MUSIC_ADDRESS") + "/info")
-	if err != nil {
-		ctx.JSON(520, errors.GetHTTPErrorWithMessage(err, ""))
+}
+
+// queryOperations builds the operations of a song update from the query parameters.
+// On error, it also returns a message describing the bad parameter
+func queryOperations(ctx *gin.Context) ([]models.SongOperation, string, error) {
+	var operations []models.SongOperation
+	if name := ctx.Query("name"); name != "" {
+		operations = append(operations, models.SongOperation{Op: models.SongOpRename, Name: name})
+	}
+	if newGroup := ctx.Query("newGroup"); newGroup != "" {
+		operations = append(operations, models.SongOperation{Op: models.SongOpAddGroup, Name: newGroup})
+	}
+	if deleteGroupIdStr := ctx.Query("groupToDelete"); deleteGroupIdStr != "" {
+		deleteGroupId, err := strconv.Atoi(deleteGroupIdStr)
+		if err != nil {
+			return nil, "id for deleting group is not a number", err
+		}
+		operations = append(operations, models.SongOperation{Op: models.SongOpRemoveGroup, GroupId: deleteGroupId})
+	}
+
+	if newVerseIdPrevStr := ctx.Query("newVersePrevId"); newVerseIdPrevStr != "" {
+		newVerseIdPrev, err := strconv.Atoi(newVerseIdPrevStr)
+		if err != nil {
+			return nil, "id of previous verse is not a number", err
+		}
+		newVerseRepeatOf, err := parseOptionalId(ctx.Query("newVerseRepeatOf"))
+		if err != nil {
+			return nil, "id of repeated verse is not a number", err
+		}
+		operations = append(operations, models.SongOperation{
+			Op:           models.SongOpInsertVerse,
+			AfterVerseId: newVerseIdPrev,
+			Text:         ctx.Query("newVerseText"),
+			Kind:         ctx.Query("newVerseKind"),
+			Label:        ctx.Query("newVerseLabel"),
+			RepeatOf:     newVerseRepeatOf,
+		})
+	}
+
+	if changeVerseIdStr := ctx.Query("verseId"); changeVerseIdStr != "" {
+		changeVerseId, err := strconv.Atoi(changeVerseIdStr)
+		if err != nil {
+			return nil, "id of verse for changing is not a number", err
+		}
+		changeVerseRepeatOf, err := parseOptionalId(ctx.Query("verseRepeatOf"))
+		if err != nil {
+			return nil, "id of repeated verse is not a number", err
+		}
+		operations = append(operations, models.SongOperation{
+			Op:       models.SongOpUpdateVerse,
+			VerseId:  changeVerseId,
+			Text:     ctx.Query("verseText"),
+			Kind:     ctx.Query("verseKind"),
+			Label:    ctx.Query("verseLabel"),
+			RepeatOf: changeVerseRepeatOf,
+		})
+	}
+
+	if deleteVerseIdStr := ctx.Query("deleteVerseId"); deleteVerseIdStr != "" {
+		deleteVerseId, err := strconv.Atoi(deleteVerseIdStr)
+		if err != nil {
+			return nil, "id for deleting verse is not a number", err
+		}
+		operations = append(operations, models.SongOperation{Op: models.SongOpDeleteVerse, VerseId: deleteVerseId})
+	}
+	return operations, "", nil
+}
+
+// AddSong Handler to add a song to the library
+//
+//	@Summary		Add a song to the library
+//	@Description	The release date, lyrics and link of the song are taken from the music API
+//	@Description	If the group is new, existing groups with similar names are returned in similarGroups
+//	@Description	502 or 503 are returned if the music API fails or is considered down
+//	@Description	With async=true the song is added at once with only its name and group and 202 is returned with the id of
+//	@Description	the job filling in the rest in the background, see /jobs/{id}. jobId is 0 if the song is already in the library
+//	@Tags			song
+//	@Produce		json
+//	@Param			input							body		models.ApiMusicRequest	true	"Data for adding a song"
+//	@Param			async							query		bool					false	"add the song without waiting for the music API"
+//	@Success		200								{object}	models.AddSongResponse
+//	@Success		202								{object}	models.AddSongAsyncResponse
+//	@Failure		400,401,403,404,429,500,502,503	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song [post]
+func (h *Handler) AddSong(ctx *gin.Context) {
+	const op = "handler.song.AddSong"
+	var input models.ApiMusicRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
 		return
 	}
-	h.logger.Info("Adding new song", slog.String("group", input.Group), slog.String("song", input.Song))
-	musicData := models.ApiMusicResponse{}
-	err = json.Unmarshal(resp.Body(), &musicData)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errors.GetHTTPError(
-			errors.NewMusicLibraryError(errors.InternalError, err)),
-		)
+	async := false
+	if asyncStr := ctx.Query("async"); asyncStr != "" {
+		var err error
+		async, err = strconv.ParseBool(asyncStr)
+		if err != nil {
+			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+			ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "async is not a boolean"))
+			return
+		}
+	}
+	if async {
+		h.addSongAsync(ctx, input)
 		return
 	}
 
-	h.logger.Debug("Data from music api", slog.Any("data", musicData))
+	h.logger.Info("Adding new song", slog.String("group", input.Group), slog.String("song", input.Song))
 
-	id, err := h.songService.AddSong(input.Group, input.Song, musicData)
+	id, similarGroups, err := h.songService.AddSong(ctx, input.Group, input.Song, auditActor(ctx))
 	if err != nil {
 		h.logger.Error("Error while adding new song " + op + ": " + err.Error())
-		ctx.JSON(http.StatusInternalServerError, errors.GetHTTPError(err))
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
 		return
 	}
 
@@ -290,7 +585,49 @@ func (h *Handler) AddSong(ctx *gin.Context) {
 		Status:  http.StatusOK,
 		Message: "ok",
 		Payload: gin.H{
-			"id": id,
+			"id":            id,
+			"similarGroups": similarGroups,
 		},
 	})
 }
+
+func (h *Handler) addSongAsync(ctx *gin.Context, input models.ApiMusicRequest) {
+	const op = "handler.song.addSongAsync"
+	h.logger.Info("Adding new song asynchronously", slog.String("group", input.Group), slog.String("song", input.Song))
+
+	id, jobId, err := h.jobService.AddSongAsync(input.Group, input.Song, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while adding new song " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	status := http.StatusAccepted
+	if jobId == 0 {
+		status = http.StatusOK
+	}
+	h.logger.Info("New song added", slog.String("group", input.Group), slog.String("song", input.Song),
+		slog.Int("jobId", jobId))
+
+	ctx.JSON(status, models.Response{
+		Status:  status,
+		Message: "ok",
+		Payload: gin.H{
+			"id":    id,
+			"jobId": jobId,
+		},
+	})
+}
+
+// parseOptionalId parses an id from a query parameter, an empty parameter gives nil
+func parseOptionalId(idStr string) (*int, error) {
+	if idStr == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
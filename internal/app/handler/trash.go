@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// GetTrash Handler to get a list of deleted songs
+//
+//	@Summary		Get a list of deleted songs
+//	@Description	Supports pagination(limit, page params), the latest deleted songs come first
+//	@Tags			trash
+//	@Produce		json
+//	@Param			limit				query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int	false	"page of data that you want to receive"	default(0)	example(1)
+//	@Success		200					{object}	models.TrashResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/trash [get]
+func (h *Handler) GetTrash(ctx *gin.Context) {
+	const op = "handler.trash.GetTrash"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting trash")
+
+	count, songs, err := h.trashService.GetTrash(limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting trash " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got trash", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count": count,
+			"songs": songs,
+		},
+	})
+}
+
+// RestoreSong Handler to restore a deleted song
+//
+//	@Summary		Restore a deleted song
+//	@Description	A song of the same group with the same name added after the deletion is a conflict
+//	@Tags			trash
+//	@Produce		json
+//	@Param			id							path		int	true	"id of the deleted song"
+//	@Success		200							{object}	models.Response
+//	@Failure		400,401,403,404,409,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/song/{id}/restore [post]
+func (h *Handler) RestoreSong(ctx *gin.Context) {
+	const op = "handler.trash.RestoreSong"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Restoring song", slog.Int("id", id))
+
+	err = h.trashService.RestoreSong(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while restoring song " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Song restored", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// PurgeSong Handler to delete a song in the trash for good
+//
+//	@Summary		Delete a song in the trash for good
+//	@Description	The song is removed together with all its verses, it cannot be restored
+//	@Tags			trash
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the deleted song"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Router			/trash/{id} [delete]
+func (h *Handler) PurgeSong(ctx *gin.Context) {
+	const op = "handler.trash.PurgeSong"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+
+	h.logger.Info("Purging song", slog.Int("id", id))
+
+	err = h.trashService.PurgeSong(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while purging song " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Song purged", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// GetUsers Handler to get a list of users
+//
+//	@Summary		Get a list of users
+//	@Description	Supports pagination(limit, page params)
+//	@Tags			user
+//	@Produce		json
+//	@Param			limit				query		int	false	"limit of received data"				default(10)	example(10)
+//	@Param			page				query		int	false	"page of data that you want to receive"	default(0)	example(2)
+//	@Success		200					{object}	models.UsersResponse
+//	@Failure		400,401,403,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/user [get]
+func (h *Handler) GetUsers(ctx *gin.Context) {
+	const op = "handler.user.GetUsers"
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	pageStr := ctx.Query("page")
+	if pageStr == "" {
+		pageStr = "0"
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "limit is not a number"))
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "page is not a number"))
+		return
+	}
+
+	h.logger.Info("Getting users")
+
+	count, users, err := h.userService.GetUsers(limit, page)
+	if err != nil {
+		h.logger.Error("Error while getting users " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("Got users", slog.Int("rowsCount", count))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: gin.H{
+			"count": count,
+			"users": users,
+		},
+	})
+}
+
+// ChangeUserRole Handler to change the role of a user
+//
+//	@Summary		Change the role of a user
+//	@Description	The user gets the role with the next tokens, when logging in or refreshing them
+//	@Description	Users can not change their own role
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			id						path		int					true	"id of the chosen user"
+//	@Param			input					body		models.RoleRequest	true	"New role of the user"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/user/{id}/role [put]
+func (h *Handler) ChangeUserRole(ctx *gin.Context) {
+	const op = "handler.user.ChangeUserRole"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	var input models.RoleRequest
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "bad format of input data"))
+		return
+	}
+	if user, _ := currentUser(ctx); user.Id == id {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("user %d changes own role", id))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "can not change own role"))
+		return
+	}
+
+	h.logger.Info("Changing user role", slog.Int("id", id), slog.String("role", input.Role))
+
+	err = h.userService.ChangeRole(id, input.Role, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while changing user role " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("User role changed", slog.Int("id", id), slog.String("role", input.Role))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
+
+// DeleteUser Handler to delete a user
+//
+//	@Summary		Delete a user
+//	@Description	Tokens of the user can not be refreshed any more, users can not delete themselves
+//	@Tags			user
+//	@Produce		json
+//	@Param			id						path		int	true	"id of the chosen user"
+//	@Success		200						{object}	models.Response
+//	@Failure		400,401,403,404,429,500	{object}	errors.MusicLibraryError
+//	@Security		BearerAuth
+//	@Router			/user/{id} [delete]
+func (h *Handler) DeleteUser(ctx *gin.Context) {
+	const op = "handler.user.DeleteUser"
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "id is not a number"))
+		return
+	}
+	if user, _ := currentUser(ctx); user.Id == id {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("user %d deletes itself", id))
+		ctx.JSON(http.StatusBadRequest, errors.GetHTTPErrorWithMessage(mlErr, "can not delete yourself"))
+		return
+	}
+
+	h.logger.Info("Deleting user", slog.Int("id", id))
+
+	err = h.userService.DeleteUser(id, auditActor(ctx))
+	if err != nil {
+		h.logger.Error("Error while deleting user " + op + ": " + err.Error())
+		mlErr := errors.GetHTTPError(err)
+		ctx.JSON(mlErr.Status, mlErr)
+		return
+	}
+
+	h.logger.Info("User deleted", slog.Int("id", id))
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Payload: nil,
+	})
+}
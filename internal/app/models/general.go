@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Response struct {
 	Status  int    `json:"status" example:"200"`
 	Message string `json:"message" example:"ok"`
@@ -16,3 +18,107 @@ type ApiMusicResponse struct {
 	Text        string `json:"text"`
 	Link        string `json:"link"`
 }
+
+type AlbumRequest struct {
+	Name        string `json:"name" binding:"required" example:"Black Holes and Revelations"`
+	ReleaseDate string `json:"releaseDate" example:"03.07.2006"`
+}
+
+type AlbumTrackRequest struct {
+	SongId      int `json:"songId" binding:"required" example:"458"`
+	DiscNumber  int `json:"discNumber" binding:"omitempty,min=1" example:"1"`
+	TrackNumber int `json:"trackNumber" binding:"required,min=1" example:"3"`
+}
+
+type CredentialsRequest struct {
+	Username string `json:"username" binding:"required,max=64" example:"matt"`
+	// bcrypt uses only the first 72 bytes of a password
+	Password string `json:"password" binding:"required,min=8,max=72" example:"supermassive"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role" binding:"required" example:"editor"`
+}
+
+type ApiKeyRequest struct {
+	Name   string   `json:"name" binding:"required" example:"lyrics-bot"`
+	Scopes []string `json:"scopes" binding:"required,min=1" example:"library:read,song:write"`
+	// ExpiresAt is the moment the key stops working, the key does not expire if it is not set
+	ExpiresAt *time.Time `json:"expiresAt" example:"2027-01-01T00:00:00Z"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// Tokens are the JWT access and refresh tokens of a user, the access token expires in ExpiresIn seconds
+type Tokens struct {
+	AccessToken  string `json:"accessToken" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refreshToken" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType    string `json:"tokenType" example:"Bearer"`
+	ExpiresIn    int    `json:"expiresIn" example:"900"`
+}
+
+type PlaylistRequest struct {
+	Name string `json:"name" binding:"required" example:"Road trip"`
+}
+
+type PlaylistItemRequest struct {
+	SongId   int `json:"songId" binding:"required" example:"458"`
+	Position int `json:"position" binding:"min=0" example:"1"`
+}
+
+type MovePlaylistItemRequest struct {
+	Position int `json:"position" binding:"required,min=1" example:"3"`
+}
+
+type GroupRequest struct {
+	Name string `json:"name" binding:"required" example:"Muse"`
+}
+
+type MergeGroupsRequest struct {
+	GroupIds []int `json:"groupIds" binding:"required" example:"27,31"`
+}
+
+type SongUpdateRequest struct {
+	Operations []SongOperation `json:"operations" binding:"required,dive"`
+}
+
+// ImportRow is a song in a bulk import or export. A row without text gets its metadata from the music API,
+// unless it is a draft, which is added as it is. Groups lists all groups of the song including Group.
+// Verses keep the kinds, labels and timings of the verses, Text is used if they are not set
+type ImportRow struct {
+	Group       string   `json:"group" example:"Muse"`
+	Song        string   `json:"song" example:"Supermassive Black Hole"`
+	ReleaseDate string   `json:"releaseDate" example:"16.07.2006"`
+	Link        string   `json:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	Text        string   `json:"text" example:"Ooh baby, don't you know I suffer?"`
+	Groups      []string `json:"groups,omitempty" example:"Muse"`
+	Verses      []Verse  `json:"verses,omitempty"`
+	Draft       bool     `json:"draft,omitempty" example:"false"`
+}
+
+// ImportResult is the outcome of a row of a bulk import
+type ImportResult struct {
+	Row    int    `json:"row" example:"1"`
+	Group  string `json:"group" example:"Muse"`
+	Song   string `json:"song" example:"Supermassive Black Hole"`
+	Status string `json:"status" example:"created"`
+	Id     int    `json:"id,omitempty" example:"458"`
+	JobId  int    `json:"jobId,omitempty" example:"17"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	ImportStatusCreated  = "created"
+	ImportStatusExisting = "existing"
+	ImportStatusFailed   = "failed"
+)
+
+// ImportReport is the outcome of a bulk import
+type ImportReport struct {
+	Created  int            `json:"created" example:"39870"`
+	Existing int            `json:"existing" example:"112"`
+	Failed   int            `json:"failed" example:"18"`
+	Rows     []ImportResult `json:"rows"`
+}
@@ -1,15 +1,25 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 type Song struct {
-	Id          int       `json:"id" db:"id" example:"458"`
-	Name        string    `json:"name" db:"name" example:"Supermassive Black Hole"`
-	ReleaseDate time.Time `json:"releaseDate" db:"release_date" example:"16.07.2006"`
-	Link        string    `json:"link" db:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
-	Groups      []Group   `json:"groups" db:"groups"`
+	Id          int        `json:"id" db:"id" example:"458"`
+	Name        string     `json:"name" db:"name" example:"Supermassive Black Hole"`
+	ReleaseDate time.Time  `json:"releaseDate" db:"release_date" example:"16.07.2006"`
+	Link        string     `json:"link" db:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	Groups      []Group    `json:"groups" db:"groups"`
+	Score       float64    `json:"score,omitempty" db:"score" example:"0.57"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	Version     int        `json:"version,omitempty" db:"version" example:"3"`
+	VersesCount *int       `json:"versesCount,omitempty" example:"5"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+	Text        []Verse    `json:"text,omitempty"`
 }
 
 type Group struct {
@@ -18,15 +28,401 @@ type Group struct {
 }
 
 type Verse struct {
-	Id   int    `json:"verseId" db:"id" example:"89"`
-	Text string `json:"text" db:"text" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?"`
+	Id       int    `json:"verseId" db:"id" example:"89"`
+	Kind     string `json:"kind" db:"kind" example:"chorus"`
+	Label    string `json:"label,omitempty" db:"label" example:"Chorus"`
+	RepeatOf *int   `json:"repeatOf,omitempty" db:"repeat_of" example:"87"`
+	Text     string `json:"text" db:"text" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?"`
+
+	StartMs      *int      `json:"startMs,omitempty" db:"start_ms" example:"31200"`
+	EndMs        *int      `json:"endMs,omitempty" db:"end_ms" example:"46850"`
+	LineStartsMs LineTimes `json:"lineStartsMs,omitempty" db:"line_starts_ms"`
+}
+
+// LineTimes holds the start times of the lines of a verse in milliseconds, it is stored as a JSON array
+type LineTimes []int
+
+func (l LineTimes) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal([]int(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (l *LineTimes) Scan(src any) error {
+	switch data := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, (*[]int)(l))
+	case string:
+		return json.Unmarshal([]byte(data), (*[]int)(l))
+	}
+	return fmt.Errorf("unsupported type %T of line times", src)
+}
+
+// VerseDraft is a verse that is not stored yet.
+// Repeats is the index of the draft in the same list whose text this verse repeats
+type VerseDraft struct {
+	Text    string
+	Kind    string
+	Label   string
+	Repeats *int
+
+	StartMs      *int
+	EndMs        *int
+	LineStartsMs LineTimes
+}
+
+const (
+	VerseKindIntro  = "intro"
+	VerseKindVerse  = "verse"
+	VerseKindChorus = "chorus"
+	VerseKindBridge = "bridge"
+	VerseKindOutro  = "outro"
+	VerseKindOther  = "other"
+)
+
+func IsVerseKind(kind string) bool {
+	switch kind {
+	case VerseKindIntro, VerseKindVerse, VerseKindChorus, VerseKindBridge, VerseKindOutro, VerseKindOther:
+		return true
+	}
+	return false
+}
+
+type Album struct {
+	Id          int          `json:"id" db:"id" example:"12"`
+	Name        string       `json:"name" db:"name" example:"Black Holes and Revelations"`
+	ReleaseDate time.Time    `json:"releaseDate" db:"release_date" example:"03.07.2006"`
+	Tracks      []AlbumTrack `json:"tracks,omitempty"`
+}
+
+type AlbumTrack struct {
+	DiscNumber  int    `json:"discNumber" db:"disc_number" example:"1"`
+	TrackNumber int    `json:"trackNumber" db:"track_number" example:"3"`
+	SongId      int    `json:"songId" db:"song_id" example:"458"`
+	SongName    string `json:"songName" db:"song_name" example:"Supermassive Black Hole"`
+}
+
+type Playlist struct {
+	Id         int       `json:"id" db:"id" example:"5"`
+	Name       string    `json:"name" db:"name" example:"Road trip"`
+	ItemsCount int       `json:"itemsCount" db:"items_count" example:"12"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// PlaylistItem is a song at a position of a playlist, positions start from 1.
+// A song may be in a playlist more than once, so items are addressed by their own id
+type PlaylistItem struct {
+	Id       int  `json:"itemId" example:"31"`
+	Position int  `json:"position" example:"1"`
+	Song     Song `json:"song"`
+}
+
+// PlaylistItemDBFormat is a row of a playlist item joined with a group of its song
+type PlaylistItemDBFormat struct {
+	ItemId   int `db:"item_id"`
+	Position int `db:"position"`
+	SongDBFormat
+}
+
+type User struct {
+	Id           int       `json:"id" db:"id" example:"7"`
+	Username     string    `json:"username" db:"username" example:"matt"`
+	Role         string    `json:"role" db:"role" example:"editor"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// ApiKey is a key of a bot. Only the hash of the key is stored, the key is shown once when it is made
+type ApiKey struct {
+	Id         int        `json:"id" db:"id" example:"3"`
+	Name       string     `json:"name" db:"name" example:"lyrics-bot"`
+	Prefix     string     `json:"prefix" db:"prefix" example:"5f2b9c0d41e7a386"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     Scopes     `json:"scopes" db:"scopes" example:"library:read,song:write"`
+	CreatedBy  *int       `json:"createdBy,omitempty" db:"created_by" example:"7"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+// Scopes are the permissions of an API key, they are stored as a JSON array
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	if s == nil {
+		s = Scopes{}
+	}
+	data, err := json.Marshal([]string(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (s *Scopes) Scan(src any) error {
+	switch data := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, (*[]string)(s))
+	case string:
+		return json.Unmarshal([]byte(data), (*[]string)(s))
+	}
+	return fmt.Errorf("unsupported type %T of scopes", src)
+}
+
+type SearchHit struct {
+	SongId  int     `json:"songId" db:"song_id" example:"458"`
+	Name    string  `json:"name" db:"name" example:"Supermassive Black Hole"`
+	Rank    float64 `json:"rank" db:"rank" example:"0.6"`
+	VerseId *int    `json:"verseId,omitempty" db:"verse_id" example:"89"`
+	Snippet string  `json:"snippet" db:"snippet" example:"Ooh <b>baby</b>, don't you know I suffer?"`
+}
+
+type LibraryFilter struct {
+	SearchText string
+	DateFrom   time.Time
+	DateTo     time.Time
+	AlbumId    int
+	Fuzzy      bool
+	Sort       []SortField
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Cursor points at the last item of a page for keyset pagination.
+// Keys are the values of the sort keys of the item, Id is its id
+type Cursor struct {
+	Keys []string `json:"k,omitempty"`
+	Id   int      `json:"id"`
+}
+
+// SongOperation is one change of a song in an update. Which fields are used depends on Op:
+// rename and addGroup use Name, removeGroup uses GroupId, setLink uses Link, setReleaseDate uses ReleaseDate,
+// insertVerse uses AfterVerseId and the verse fields, updateVerse uses VerseId and the verse fields,
+// deleteVerse uses VerseId, moveVerse uses VerseId and AfterVerseId
+type SongOperation struct {
+	Op           string `json:"op" binding:"required" example:"insertVerse" enums:"rename,addGroup,removeGroup,setLink,setReleaseDate,insertVerse,updateVerse,deleteVerse,moveVerse"`
+	Name         string `json:"name,omitempty" example:"Muse"`
+	GroupId      int    `json:"groupId,omitempty" example:"26"`
+	Link         string `json:"link,omitempty" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	ReleaseDate  *Date  `json:"releaseDate,omitempty" swaggertype:"string" example:"16.07.2006"`
+	VerseId      int    `json:"verseId,omitempty" example:"89"`
+	AfterVerseId int    `json:"afterVerseId,omitempty" example:"87"`
+	Text         string `json:"text,omitempty" example:"Ooh baby, don't you know I suffer?"`
+	Kind         string `json:"kind,omitempty" example:"chorus"`
+	Label        string `json:"label,omitempty" example:"Chorus 2"`
+	RepeatOf     *int   `json:"repeatOf,omitempty" example:"87"`
 }
 
+const (
+	SongOpRename         = "rename"
+	SongOpAddGroup       = "addGroup"
+	SongOpRemoveGroup    = "removeGroup"
+	SongOpSetLink        = "setLink"
+	SongOpSetReleaseDate = "setReleaseDate"
+	SongOpInsertVerse    = "insertVerse"
+	SongOpUpdateVerse    = "updateVerse"
+	SongOpDeleteVerse    = "deleteVerse"
+	SongOpMoveVerse      = "moveVerse"
+)
+
+// VerseFields returns the verse described by the operation
+func (o SongOperation) VerseFields() *Verse {
+	return &Verse{
+		Id:       o.VerseId,
+		Kind:     o.Kind,
+		Label:    o.Label,
+		RepeatOf: o.RepeatOf,
+		Text:     o.Text,
+	}
+}
+
+// DateLayout is the format of dates in requests
+const DateLayout = "02.01.2006"
+
+// Date is a date written in JSON in the DateLayout format
+type Date struct {
+	time.Time
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(DateLayout))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// SongDocument is the canonical JSON representation of a song that patches are applied to.
+// id, groupId of the groups and id and timings of the verses are read-only
+type SongDocument struct {
+	Id          int       `json:"id" example:"458"`
+	Name        string    `json:"name" example:"Supermassive Black Hole"`
+	ReleaseDate time.Time `json:"releaseDate" example:"2006-07-16T00:00:00Z"`
+	Link        string    `json:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	Groups      []Group   `json:"groups"`
+	Verses      []Verse   `json:"verses"`
+}
+
+// Revision is a change of a song with the state of the song before and after it
+type Revision struct {
+	Revision  int          `json:"revision" db:"revision" example:"3"`
+	SongId    int          `json:"songId" db:"song_id" example:"458"`
+	Editor    string       `json:"editor" db:"editor" example:"anna"`
+	Action    string       `json:"action" db:"action" example:"change"`
+	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
+	Before    SongSnapshot `json:"before" db:"before"`
+	After     SongSnapshot `json:"after" db:"after"`
+}
+
+const (
+	RevisionActionChange  = "change"
+	RevisionActionImport  = "import"
+	RevisionActionRestore = "restore"
+)
+
+// Actor is who makes a change and in which request, it is recorded in the audit log
+type Actor struct {
+	Name      string
+	RequestId string
+}
+
+// AuditEvent is a change of an entity with its state before and after it, a state is null
+// if the entity did not exist
+type AuditEvent struct {
+	Id         int             `json:"id" db:"id" example:"1042"`
+	Actor      string          `json:"actor" db:"actor" example:"anna"`
+	Action     string          `json:"action" db:"action" example:"delete"`
+	EntityType string          `json:"entityType" db:"entity_type" example:"song"`
+	EntityId   int             `json:"entityId" db:"entity_id" example:"458"`
+	RequestId  string          `json:"requestId" db:"request_id" example:"9f86d081884c7d65"`
+	CreatedAt  time.Time       `json:"createdAt" db:"created_at"`
+	Before     json.RawMessage `json:"before" db:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" db:"after" swaggertype:"object"`
+}
+
+const (
+	AuditActionAdd     = "add"
+	AuditActionChange  = "change"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionMerge   = "merge"
+
+	AuditEntitySong     = "song"
+	AuditEntityGroup    = "group"
+	AuditEntityAlbum    = "album"
+	AuditEntityPlaylist = "playlist"
+	AuditEntityUser     = "user"
+	AuditEntityApiKey   = "apikey"
+)
+
+// AuditEntities are the types of the entities whose changes are recorded in the audit log
+var AuditEntities = []string{AuditEntitySong, AuditEntityGroup, AuditEntityAlbum, AuditEntityPlaylist,
+	AuditEntityUser, AuditEntityApiKey}
+
+// AuditFilter selects audit events, zero fields are not used
+type AuditFilter struct {
+	EntityType string
+	EntityId   int
+	Actor      string
+	From       time.Time
+	To         time.Time
+}
+
+// Job is a background job filling a draft song with the metadata from the music API
+type Job struct {
+	Id        int       `json:"id" db:"id" example:"17"`
+	SongId    int       `json:"songId" db:"song_id" example:"458"`
+	Group     string    `json:"group" db:"group_name" example:"Muse"`
+	Song      string    `json:"song" db:"song_name" example:"Supermassive Black Hole"`
+	Status    string    `json:"status" db:"status" example:"pending"`
+	Attempts  int       `json:"attempts" db:"attempts" example:"1"`
+	LastError *string   `json:"lastError,omitempty" db:"last_error"`
+	RunAt     time.Time `json:"runAt" db:"run_at"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// SongSnapshot is the state of the name, groups and verses of a song, it is stored as a JSON object
+type SongSnapshot struct {
+	Name   string  `json:"name" example:"Supermassive Black Hole"`
+	Groups []Group `json:"groups"`
+	Verses []Verse `json:"verses"`
+}
+
+func (s SongSnapshot) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (s *SongSnapshot) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	}
+	return fmt.Errorf("unsupported type %T of song snapshot", src)
+}
+
+// DiffLine is a line of a diff: an unchanged, a deleted or an inserted line
+type DiffLine struct {
+	Op   string `json:"op" example:"insert" enums:"equal,delete,insert"`
+	Text string `json:"text" example:"Ooh baby, don't you know I suffer?"`
+}
+
+const (
+	DiffOpEqual  = "equal"
+	DiffOpDelete = "delete"
+	DiffOpInsert = "insert"
+)
+
 type SongDBFormat struct {
-	Id          int       `db:"id"`
-	Name        string    `db:"name"`
-	ReleaseDate time.Time `db:"release_date"`
-	Link        string    `db:"link"`
-	GroupId     int       `db:"group_id"`
-	GroupName   string    `db:"group_name"`
+	Id          int        `db:"id"`
+	Name        string     `db:"name"`
+	ReleaseDate time.Time  `db:"release_date"`
+	Link        string     `db:"link"`
+	GroupId     int        `db:"group_id"`
+	GroupName   string     `db:"group_name"`
+	Score       float64    `db:"score"`
+	CursorKeys  string     `db:"cursor_keys"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	Version     int        `db:"version"`
+	CreatedAt   *time.Time `db:"created_at"`
+	UpdatedAt   *time.Time `db:"updated_at"`
 }
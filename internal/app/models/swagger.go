@@ -4,8 +4,27 @@ type LibraryResponse struct {
 	Status  string `json:"status" example:"200"`
 	Message string `json:"message" example:"ok"`
 	Payload struct {
-		Count   int    `json:"count" example:"10"`
-		Library []Song `json:"library"`
+		Count      int    `json:"count" example:"10"`
+		Total      int    `json:"total" example:"134"`
+		Library    []Song `json:"library"`
+		NextCursor string `json:"nextCursor" example:"eyJpZCI6NDU4fQ"`
+	}
+}
+
+type SearchResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count int         `json:"count" example:"10"`
+		Hits  []SearchHit `json:"hits"`
+	}
+}
+
+type SongResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Song Song `json:"song"`
 	}
 }
 
@@ -13,8 +32,17 @@ type SongTextResponse struct {
 	Status  string `json:"status" example:"200"`
 	Message string `json:"message" example:"ok"`
 	Payload struct {
-		Count int    `json:"count" example:"5"`
-		Text  []Song `json:"text"`
+		Count      int     `json:"count" example:"5"`
+		Text       []Verse `json:"text"`
+		NextCursor string  `json:"nextCursor" example:"eyJpZCI6ODl9"`
+	}
+}
+
+type ImportLyricsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count int `json:"count" example:"9"`
 	}
 }
 
@@ -22,6 +50,203 @@ type AddSongResponse struct {
 	Status  string `json:"status" example:"200"`
 	Message string `json:"message" example:"ok"`
 	Payload struct {
-		Id int `json:"id" example:"48"`
+		Id            int     `json:"id" example:"48"`
+		SimilarGroups []Group `json:"similarGroups"`
+	}
+}
+
+type AddSongAsyncResponse struct {
+	Status  string `json:"status" example:"202"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Id    int `json:"id" example:"48"`
+		JobId int `json:"jobId" example:"17"`
+	}
+}
+
+type JobResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Job Job `json:"job"`
+	}
+}
+
+type ImportResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Report ImportReport `json:"report"`
+	}
+}
+
+type AlbumsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count  int     `json:"count" example:"10"`
+		Albums []Album `json:"albums"`
+	}
+}
+
+type AlbumResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload Album
+}
+
+type AddAlbumResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Id int `json:"id" example:"12"`
+	}
+}
+
+type RegisterResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Id int `json:"id" example:"7"`
+	}
+}
+
+type TokensResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload Tokens
+}
+
+type UsersResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count int    `json:"count" example:"10"`
+		Users []User `json:"users"`
+	}
+}
+
+type PlaylistsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count     int        `json:"count" example:"10"`
+		Playlists []Playlist `json:"playlists"`
+	}
+}
+
+type PlaylistResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload Playlist
+}
+
+type AddPlaylistResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Id int `json:"id" example:"5"`
+	}
+}
+
+type PlaylistItemsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count int            `json:"count" example:"10"`
+		Items []PlaylistItem `json:"items"`
+	}
+}
+
+type AddPlaylistItemResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		ItemId int `json:"itemId" example:"31"`
+	}
+}
+
+type GroupsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count  int     `json:"count" example:"10"`
+		Groups []Group `json:"groups"`
+	}
+}
+
+type GroupResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload Group
+}
+
+type GroupSongsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count int    `json:"count" example:"10"`
+		Songs []Song `json:"songs"`
+	}
+}
+
+type HistoryResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count     int        `json:"count" example:"3"`
+		Revisions []Revision `json:"revisions"`
+	}
+}
+
+type RevisionDiffResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Diff []DiffLine `json:"diff"`
+	}
+}
+
+type RestoreRevisionResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Revision int `json:"revision" example:"4"`
+	}
+}
+
+type TrashResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count int    `json:"count" example:"2"`
+		Songs []Song `json:"songs"`
+	}
+}
+
+type ApiKeysResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count   int      `json:"count" example:"10"`
+		ApiKeys []ApiKey `json:"apiKeys"`
+	}
+}
+
+type ApiKeyCreatedResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Id  int    `json:"id" example:"3"`
+		Key string `json:"key" example:"mlk_5f2b9c0d41e7a386_Qm9vdHN0cmFwIGtleSBmb3IgdGhlIGx5cmljcyBib3Q"`
+	}
+}
+
+type AuditEventsResponse struct {
+	Status  string `json:"status" example:"200"`
+	Message string `json:"message" example:"ok"`
+	Payload struct {
+		Count  int          `json:"count" example:"10"`
+		Events []AuditEvent `json:"events"`
 	}
 }
@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Permissions checked by the routes
+const (
+	LibraryRead   = "library:read"
+	SongWrite     = "song:write"
+	SongDelete    = "song:delete"
+	AlbumWrite    = "album:write"
+	GroupManage   = "group:manage"
+	PlaylistWrite = "playlist:write"
+	UserManage    = "user:manage"
+	ApiKeyManage  = "apikey:manage"
+	AuditRead     = "audit:read"
+
+	// All grants every permission
+	All = "*"
+)
+
+// Roles of the default policy, a new user is a viewer
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// defaultRoles lets viewers read the library, editors change songs, albums and the playlists,
+// which are shared by all users, and admins do everything
+var defaultRoles = map[string][]string{
+	RoleViewer: {LibraryRead},
+	RoleEditor: {LibraryRead, PlaylistWrite, SongWrite, AlbumWrite},
+	RoleAdmin:  {All},
+}
+
+// ApiKeyScopes are the permissions that can be given to API keys, keys can not manage users or other keys
+var ApiKeyScopes = []string{LibraryRead, SongWrite, SongDelete}
+
+// IsApiKeyScope reports whether the permission can be given to an API key
+func IsApiKeyScope(permission string) bool {
+	return slices.Contains(ApiKeyScopes, permission)
+}
+
+// Policy maps roles to their permissions
+type Policy struct {
+	roles map[string]map[string]bool
+}
+
+func New(roles map[string][]string) *Policy {
+	p := &Policy{roles: make(map[string]map[string]bool, len(roles))}
+	for role, permissions := range roles {
+		p.roles[role] = make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			p.roles[role][permission] = true
+		}
+	}
+	return p
+}
+
+// Default returns the policy with the viewer, editor and admin roles
+func Default() *Policy {
+	return New(defaultRoles)
+}
+
+// Load reads a policy from a JSON file mapping roles to lists of permissions, like
+// {"viewer": ["library:read"], "admin": ["*"]}. An empty path gives the default policy
+func Load(path string) (*Policy, error) {
+	const op = "policy.Load"
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var roles map[string][]string
+	if err = json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("%s (bad policy %s): %w", op, path, err)
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("%s: policy %s has no roles", op, path)
+	}
+	return New(roles), nil
+}
+
+// Allows reports whether the role has the permission, an unknown role has none
+func (p *Policy) Allows(role string, permission string) bool {
+	permissions := p.roles[role]
+	return permissions[permission] || permissions[All]
+}
+
+// HasRole reports whether the role is defined by the policy
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.roles[role]
+	return ok
+}
+
+// Roles returns the roles of the policy in alphabetical order
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.roles))
+	for role := range p.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	// a step is allow (a), success (s), failure (f) or the end of the cooldown (w),
+	// want has the outcome of every allow in order
+	tests := []struct {
+		name  string
+		steps string
+		want  []bool
+	}{
+		{name: "closed", steps: "afafa", want: []bool{true, true, true}},
+		{name: "success resets failures", steps: "ffsffa", want: []bool{true}},
+		{name: "opens after threshold", steps: "fffa", want: []bool{false}},
+		{name: "single trial after cooldown", steps: "fffwaa", want: []bool{true, false}},
+		{name: "trial success closes", steps: "fffwasaa", want: []bool{true, true, true}},
+		{name: "trial failure opens again", steps: "fffwafa", want: []bool{true, false}},
+		{name: "trial failure and cooldown", steps: "fffwafwa", want: []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(3, time.Hour)
+			var got []bool
+			for _, step := range tt.steps {
+				switch step {
+				case 'a':
+					got = append(got, b.allow())
+				case 's':
+					b.success()
+				case 'f':
+					b.failure()
+				case 'w':
+					b.openUntil = time.Now().Add(-time.Second)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"sync"
+	"time"
+)
+
+type cacheKey struct {
+	group string
+	song  string
+}
+
+type cacheEntry struct {
+	info      models.ApiMusicResponse
+	expiresAt time.Time
+}
+
+// cache keeps song metadata by group and song for the ttl
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[cacheKey]cacheEntry
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:     ttl,
+		entries: make(map[cacheKey]cacheEntry),
+	}
+}
+
+func (c *cache) get(group string, song string) (models.ApiMusicResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := cacheKey{group: group, song: song}
+	entry, ok := c.entries[key]
+	if !ok {
+		return models.ApiMusicResponse{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return models.ApiMusicResponse{}, false
+	}
+	return entry.info, true
+}
+
+// put caches the metadata, expired entries are dropped on the way
+func (c *cache) put(group string, song string, info models.ApiMusicResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[cacheKey{group: group, song: song}] = cacheEntry{info: info, expiresAt: now.Add(c.ttl)}
+}
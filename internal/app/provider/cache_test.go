@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	info := models.ApiMusicResponse{ReleaseDate: "14.09.2009", Text: "Paranoia is in bloom"}
+	tests := []struct {
+		name    string
+		age     time.Duration
+		group   string
+		song    string
+		want    models.ApiMusicResponse
+		wantHit bool
+	}{
+		{name: "fresh", age: time.Minute, group: "Muse", song: "Uprising", want: info, wantHit: true},
+		{name: "expired", age: 11 * time.Minute, group: "Muse", song: "Uprising"},
+		{name: "other song", group: "Muse", song: "Resistance"},
+		{name: "other group", group: "Guest", song: "Uprising"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCache(10 * time.Minute)
+			c.put("Muse", "Uprising", info)
+			// the entry is made older by moving its expiry back
+			key := cacheKey{group: "Muse", song: "Uprising"}
+			entry := c.entries[key]
+			entry.expiresAt = entry.expiresAt.Add(-tt.age)
+			c.entries[key] = entry
+
+			got, hit := c.get(tt.group, tt.song)
+			if hit != tt.wantHit || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("get() = %+v, %v, want %+v, %v", got, hit, tt.want, tt.wantHit)
+			}
+			if _, kept := c.entries[key]; kept != (tt.age < 10*time.Minute) {
+				t.Errorf("entry kept = %v after get()", kept)
+			}
+		})
+	}
+}
+
+func TestCachePutDropsExpired(t *testing.T) {
+	c := newCache(10 * time.Minute)
+	c.put("Muse", "Uprising", models.ApiMusicResponse{})
+	key := cacheKey{group: "Muse", song: "Uprising"}
+	entry := c.entries[key]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	c.entries[key] = entry
+
+	c.put("Muse", "Resistance", models.ApiMusicResponse{})
+	if _, ok := c.entries[key]; ok || len(c.entries) != 1 {
+		t.Errorf("entries = %+v, want only the new one", c.entries)
+	}
+}
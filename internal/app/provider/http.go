@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultRetries          = 3
+	defaultRetryWait        = 200 * time.Millisecond
+	defaultRetryMaxWait     = 2 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	defaultCacheTTL         = 10 * time.Minute
+)
+
+// Config of the HTTP metadata provider, zero values are replaced with the defaults
+type Config struct {
+	Address string
+	// Timeout of a single attempt
+	Timeout time.Duration
+	// Retries is the number of retries after a failed attempt, the wait between them grows exponentially
+	// from RetryWait to RetryMaxWait
+	Retries      int
+	RetryWait    time.Duration
+	RetryMaxWait time.Duration
+	// BreakerThreshold is the number of failed requests in a row that opens the circuit breaker
+	// for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	CacheTTL         time.Duration
+}
+
+// HTTPProvider gets song metadata from the external music API
+type HTTPProvider struct {
+	client  *resty.Client
+	breaker *breaker
+	cache   *cache
+}
+
+func NewHTTPProvider(cfg Config) *HTTPProvider {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.RetryWait == 0 {
+		cfg.RetryWait = defaultRetryWait
+	}
+	if cfg.RetryMaxWait == 0 {
+		cfg.RetryMaxWait = defaultRetryMaxWait
+	}
+	if cfg.BreakerThreshold == 0 {
+		cfg.BreakerThreshold = defaultBreakerThreshold
+	}
+	if cfg.BreakerCooldown == 0 {
+		cfg.BreakerCooldown = defaultBreakerCooldown
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+
+	client := resty.New().
+		SetBaseURL(cfg.Address).
+		SetTimeout(cfg.Timeout).
+		SetRetryCount(cfg.Retries).
+		SetRetryWaitTime(cfg.RetryWait).
+		SetRetryMaxWaitTime(cfg.RetryMaxWait).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			return err == nil && isTemporary(resp.StatusCode())
+		})
+
+	return &HTTPProvider{
+		client:  client,
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		cache:   newCache(cfg.CacheTTL),
+	}
+}
+
+// GetSongInfo returns the metadata of the song. Successful responses are cached
+func (p *HTTPProvider) GetSongInfo(ctx context.Context, group string, song string) (models.ApiMusicResponse, error) {
+	const op = "provider.http.GetSongInfo"
+	if info, ok := p.cache.get(group, song); ok {
+		return info, nil
+	}
+
+	if !p.breaker.allow() {
+		mlErr := errors2.NewMusicLibraryError(errors2.ServiceUnavailableError,
+			fmt.Errorf("music API is unavailable, circuit breaker is open"))
+		return models.ApiMusicResponse{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	resp, err := p.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"group": group,
+			"song":  song,
+		}).
+		Get("/info")
+	if err != nil {
+		p.breaker.failure()
+		mlErr := errors2.NewMusicLibraryError(errors2.BadGatewayError, err)
+		return models.ApiMusicResponse{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	status := resp.StatusCode()
+	if isTemporary(status) {
+		p.breaker.failure()
+	} else {
+		// the API answered, even if the request was bad
+		p.breaker.success()
+	}
+	if !resp.IsSuccess() {
+		mlErr := errors2.NewMusicLibraryError(statusError(status), fmt.Errorf("music API responded with %s", resp.Status()))
+		return models.ApiMusicResponse{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	var info models.ApiMusicResponse
+	if err = json.Unmarshal(resp.Body(), &info); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.BadGatewayError, fmt.Errorf("bad response of music API: %w", err))
+		return models.ApiMusicResponse{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	p.cache.put(group, song, info)
+	return info, nil
+}
+
+// isTemporary reports whether a request that got the status may succeed if repeated
+func isTemporary(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// statusError returns the music library error for an unsuccessful status of the music API
+func statusError(status int) errors2.MusicLibraryError {
+	switch {
+	case status == http.StatusNotFound:
+		return errors2.NotFoundError
+	case isTemporary(status):
+		return errors2.BadGatewayError
+	case status >= http.StatusBadRequest:
+		return errors2.BadRequestError
+	default:
+		return errors2.BadGatewayError
+	}
+}
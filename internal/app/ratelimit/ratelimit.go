@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets that are full again are dropped
+const sweepInterval = time.Minute
+
+// Config of a token bucket
+type Config struct {
+	// Rate is the number of requests a client gets per minute
+	Rate int
+	// Burst is the size of the bucket, the number of requests a client can make at once
+	Burst int
+}
+
+var (
+	// DefaultReadConfig is the budget of the routes that read the library
+	DefaultReadConfig = Config{Rate: 300, Burst: 60}
+	// DefaultWriteConfig is the budget of the routes that change data, adding a song calls the music API
+	DefaultWriteConfig = Config{Rate: 30, Burst: 10}
+	// DefaultIPConfig is the budget of an IP spent before its requests are authenticated,
+	// so that guessing tokens and keys is limited too
+	DefaultIPConfig = Config{Rate: 600, Burst: 120}
+)
+
+// Result of a request to the limiter
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of requests the client can make at once after this one
+	Remaining int
+	// RetryAfter is the time until the next request is allowed, it is zero if this one is allowed
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is a token bucket limiter with a bucket for every client
+type Limiter struct {
+	mu sync.Mutex
+	// rate is the number of tokens added per second
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New returns a limiter of the config, a zero burst is the same as the rate
+func New(cfg Config) *Limiter {
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Rate
+	}
+	return &Limiter{
+		rate:    float64(cfg.Rate) / time.Minute.Seconds(),
+		burst:   float64(cfg.Burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the client with the key
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refill(b, now)
+		b.updated = now
+	}
+
+	result := Result{Limit: int(l.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.wait(1 - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = l.wait(l.burst - b.tokens)
+	return result
+}
+
+// refill returns the tokens of the bucket at the moment
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	return min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+}
+
+// wait returns the time the bucket needs to get the tokens
+func (l *Limiter) wait(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if l.rate == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that are full again, they are the same as new ones
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	// a token per second and a bucket of three tokens
+	cfg := Config{Rate: 60, Burst: 3}
+	steps := []struct {
+		name    string
+		advance time.Duration
+		key     string
+		want    Result
+	}{
+		{name: "full bucket", key: "a", want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+		{name: "second token", key: "a", want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+		{name: "last token", key: "a", want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+		{
+			name: "empty bucket",
+			key:  "a",
+			want: Result{Limit: 3, RetryAfter: time.Second, Reset: 3 * time.Second},
+		},
+		{name: "other client", key: "b", want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+		{
+			name:    "half a token",
+			advance: 500 * time.Millisecond,
+			key:     "a",
+			want:    Result{Limit: 3, RetryAfter: 500 * time.Millisecond, Reset: 2500 * time.Millisecond},
+		},
+		{
+			name:    "refilled token",
+			advance: 500 * time.Millisecond,
+			key:     "a",
+			want:    Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second},
+		},
+		{
+			name:    "refill stops at the burst",
+			advance: 10 * time.Second,
+			key:     "a",
+			want:    Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second},
+		},
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(cfg)
+	l.now = func() time.Time { return now }
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if got := l.Allow(step.key); got != step.want {
+			t.Fatalf("%s: Allow(%q) = %+v, want %+v", step.name, step.key, got, step.want)
+		}
+	}
+}
+
+func TestNewBurst(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want float64
+	}{
+		{name: "burst", cfg: Config{Rate: 30, Burst: 10}, want: 10},
+		{name: "zero burst is the rate", cfg: Config{Rate: 30}, want: 30},
+		{name: "negative burst is the rate", cfg: Config{Rate: 30, Burst: -1}, want: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.cfg).burst; got != tt.want {
+				t.Errorf("New(%+v).burst = %v, want %v", tt.cfg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(Config{Rate: 60, Burst: 3})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		l.Allow("drained")
+	}
+	now = now.Add(sweepInterval)
+	l.Allow("fresh")
+	if _, ok := l.buckets["drained"]; ok {
+		t.Errorf("the bucket of a client that is full again was not dropped")
+	}
+	if _, ok := l.buckets["fresh"]; !ok {
+		t.Errorf("the bucket of the current client was dropped")
+	}
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"strings"
+	"time"
+)
+
+type AlbumRepository struct {
+	db *sqlx.DB
+}
+
+func NewAlbumRepository(db *sqlx.DB) *AlbumRepository {
+	return &AlbumRepository{
+		db: db,
+	}
+}
+
+func (a *AlbumRepository) GetAlbums(limit int, offset int) ([]models.Album, error) {
+	const op = "repository.album.GetAlbums"
+	query := fmt.Sprintf(`SELECT id, name, COALESCE(release_date, '0001-01-01') AS release_date
+								FROM %s
+								ORDER BY id
+								LIMIT $1 OFFSET $2`, albumsTable)
+
+	albums := []models.Album{}
+	err := a.db.Select(&albums, query, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return albums, nil
+}
+
+func (a *AlbumRepository) GetAlbum(id int) (models.Album, error) {
+	const op = "repository.album.GetAlbum"
+	queryAlbum := fmt.Sprintf(`SELECT id, name, COALESCE(release_date, '0001-01-01') AS release_date
+									FROM %s WHERE id = $1`, albumsTable)
+
+	var album models.Album
+	err := a.db.Get(&album, queryAlbum, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, err)
+			return models.Album{}, fmt.Errorf("%s (album %d): %w", op, id, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.Album{}, fmt.Errorf("%s (failed get album): %w", op, mlErr)
+	}
+
+	queryTracks := fmt.Sprintf(`SELECT t.disc_number, t.track_number, s.id AS song_id, s.name AS song_name
+									FROM %s t
+									JOIN %s s ON s.id = t.song_id
+									WHERE t.album_id = $1 AND s.deleted_at IS NULL
+									ORDER BY t.disc_number, t.track_number`, albumTracksTable, songsTable)
+
+	album.Tracks = []models.AlbumTrack{}
+	err = a.db.Select(&album.Tracks, queryTracks, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.Album{}, fmt.Errorf("%s (failed get tracks): %w", op, mlErr)
+	}
+	return album, nil
+}
+
+func (a *AlbumRepository) AddAlbum(name string, releaseDate time.Time, actor models.Actor) (int, error) {
+	const op = "repository.album.AddAlbum"
+	return inAuditedTransaction(a.db, op, actor, models.AuditActionAdd, models.AuditEntityAlbum, 0, albumState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`INSERT INTO %s (name, release_date) VALUES ($1, $2) RETURNING id`, albumsTable)
+
+			var id int
+			err := tx.Get(&id, query, name, nullTime(releaseDate))
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, nil
+		})
+}
+
+func (a *AlbumRepository) ChangeAlbum(id int, name string, releaseDate time.Time, actor models.Actor) error {
+	const op = "repository.album.ChangeAlbum"
+	_, err := inAuditedTransaction(a.db, op, actor, models.AuditActionChange, models.AuditEntityAlbum, id, albumState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`UPDATE %s SET name = $1, release_date = $2 WHERE id = $3`, albumsTable)
+
+			res, err := tx.Exec(query, name, nullTime(releaseDate), id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "album", id)
+		})
+	return err
+}
+
+func (a *AlbumRepository) DeleteAlbum(id int, actor models.Actor) error {
+	const op = "repository.album.DeleteAlbum"
+	_, err := inAuditedTransaction(a.db, op, actor, models.AuditActionDelete, models.AuditEntityAlbum, id, albumState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, albumsTable)
+
+			res, err := tx.Exec(query, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "album", id)
+		})
+	return err
+}
+
+func (a *AlbumRepository) AddTrack(albumId int, track models.AlbumTrack, actor models.Actor) error {
+	const op = "repository.album.AddTrack"
+	_, err := inAuditedTransaction(a.db, op, actor, models.AuditActionChange, models.AuditEntityAlbum, albumId,
+		albumState, func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`INSERT INTO %s (album_id, song_id, disc_number, track_number)
+										VALUES ($1, $2, $3, $4)
+										ON CONFLICT (album_id, song_id)
+										DO UPDATE SET disc_number = EXCLUDED.disc_number, track_number = EXCLUDED.track_number`,
+				albumTracksTable)
+
+			_, err := tx.Exec(query, albumId, track.SongId, track.DiscNumber, track.TrackNumber)
+			if err != nil {
+				if constraint, ok := violatedForeignKey(err); ok {
+					missing := fmt.Errorf("album %d does not exist", albumId)
+					if strings.Contains(constraint, "song_id") {
+						missing = fmt.Errorf("song %d does not exist", track.SongId)
+					}
+					mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, missing)
+					return 0, fmt.Errorf("%s: %w", op, mlErr)
+				}
+				if violatedUnique(err) {
+					mlErr := errors2.NewMusicLibraryError(errors2.ConflictError, fmt.Errorf(
+						"disc %d track %d of album %d is taken", track.DiscNumber, track.TrackNumber, albumId))
+					return 0, fmt.Errorf("%s: %w", op, mlErr)
+				}
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return albumId, nil
+		})
+	return err
+}
+
+func (a *AlbumRepository) DeleteTrack(albumId int, songId int, actor models.Actor) error {
+	const op = "repository.album.DeleteTrack"
+	_, err := inAuditedTransaction(a.db, op, actor, models.AuditActionChange, models.AuditEntityAlbum, albumId,
+		albumState, func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE album_id = $1 AND song_id = $2`, albumTracksTable)
+
+			res, err := tx.Exec(query, albumId, songId)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return albumId, checkAffected(op, res, "track", songId)
+		})
+	return err
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func checkAffected(op string, res sql.Result, entity string, id int) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed get affected rows): %w", op, mlErr)
+	}
+	if affected == 0 {
+		mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("%s %d does not exist", entity, id))
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+// apiKeyTouchInterval limits how often last_used_at of a key is written
+const apiKeyTouchInterval = "1 minute"
+
+type ApiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewApiKeyRepository(db *sqlx.DB) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		db: db,
+	}
+}
+
+// AddApiKey adds the key and returns its id, createdBy is 0 if the key is not made by a user
+func (a *ApiKeyRepository) AddApiKey(key models.ApiKey, actor models.Actor) (int, error) {
+	const op = "repository.api_key.AddApiKey"
+	return inAuditedTransaction(a.db, op, actor, models.AuditActionAdd, models.AuditEntityApiKey, 0, apiKeyState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`INSERT INTO %s (name, prefix, key_hash, scopes, created_by, expires_at)
+										VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6)
+										RETURNING id`, apiKeysTable)
+
+			var createdBy int
+			if key.CreatedBy != nil {
+				createdBy = *key.CreatedBy
+			}
+			var id int
+			err := tx.Get(&id, query, key.Name, key.Prefix, key.KeyHash, key.Scopes, createdBy, key.ExpiresAt)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, nil
+		})
+}
+
+func (a *ApiKeyRepository) GetApiKeys(limit int, offset int) ([]models.ApiKey, error) {
+	const op = "repository.api_key.GetApiKeys"
+	query := fmt.Sprintf(`SELECT id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at, revoked_at
+								FROM %s ORDER BY id LIMIT $1 OFFSET $2`, apiKeysTable)
+
+	keys := []models.ApiKey{}
+	err := a.db.Select(&keys, query, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return keys, nil
+}
+
+// GetActiveApiKey returns the key with the prefix if it is neither revoked nor expired
+func (a *ApiKeyRepository) GetActiveApiKey(prefix string) (models.ApiKey, error) {
+	const op = "repository.api_key.GetActiveApiKey"
+	query := fmt.Sprintf(`SELECT id, name, prefix, key_hash, scopes, created_by, created_at, expires_at, last_used_at
+								FROM %s
+								WHERE prefix = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())`,
+		apiKeysTable)
+
+	var key models.ApiKey
+	err := a.db.Get(&key, query, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError,
+				fmt.Errorf("active api key %q does not exist", prefix))
+			return models.ApiKey{}, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.ApiKey{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return key, nil
+}
+
+// RevokeApiKey stops the key from working, a revoked key stays in the list
+func (a *ApiKeyRepository) RevokeApiKey(id int, actor models.Actor) error {
+	const op = "repository.api_key.RevokeApiKey"
+	_, err := inAuditedTransaction(a.db, op, actor, models.AuditActionChange, models.AuditEntityApiKey, id, apiKeyState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`UPDATE %s SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, apiKeysTable)
+
+			res, err := tx.Exec(query, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "active api key", id)
+		})
+	return err
+}
+
+// RotateApiKey replaces the prefix and the hash of a key that is not revoked,
+// the name, scopes and expiry of the key are kept
+func (a *ApiKeyRepository) RotateApiKey(id int, prefix string, keyHash string, actor models.Actor) error {
+	const op = "repository.api_key.RotateApiKey"
+	_, err := inAuditedTransaction(a.db, op, actor, models.AuditActionChange, models.AuditEntityApiKey, id, apiKeyState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`UPDATE %s SET prefix = $1, key_hash = $2, last_used_at = NULL
+										WHERE id = $3 AND revoked_at IS NULL`, apiKeysTable)
+
+			res, err := tx.Exec(query, prefix, keyHash, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "active api key", id)
+		})
+	return err
+}
+
+// TouchApiKey sets the last usage of the key to now. It is written at most once per apiKeyTouchInterval,
+// so busy keys do not write on every request
+func (a *ApiKeyRepository) TouchApiKey(id int) error {
+	const op = "repository.api_key.TouchApiKey"
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = now()
+								WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '%s')`,
+		apiKeysTable, apiKeyTouchInterval)
+
+	_, err := a.db.Exec(query, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"strings"
+	"time"
+)
+
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// GetAuditEvents returns the events matching the filter, the latest first
+func (a *AuditRepository) GetAuditEvents(filter models.AuditFilter, limit int, offset int) ([]models.AuditEvent, error) {
+	const op = "repository.audit.GetAuditEvents"
+	query := fmt.Sprintf(`SELECT id, actor, action, entity_type, entity_id, request_id, created_at, before, after
+								FROM %s
+								%s
+								ORDER BY created_at DESC, id DESC
+								LIMIT :limit OFFSET :offset`, auditEventsTable, auditConditions(filter))
+	query, args, err := sqlx.Named(query, map[string]interface{}{
+		"entity_type": filter.EntityType,
+		"entity_id":   filter.EntityId,
+		"actor":       filter.Actor,
+		"from":        filter.From,
+		"to":          filter.To,
+		"limit":       limit,
+		"offset":      offset,
+	})
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s (failed bind query): %w", op, mlErr)
+	}
+
+	events := []models.AuditEvent{}
+	err = a.db.Select(&events, a.db.Rebind(query), args...)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return events, nil
+}
+
+// auditConditions builds the WHERE clause of the filter, the period includes from and excludes to
+func auditConditions(filter models.AuditFilter) string {
+	var conditions []string
+	if filter.EntityType != "" {
+		conditions = append(conditions, `entity_type = :entity_type`)
+	}
+	if filter.EntityId != 0 {
+		conditions = append(conditions, `entity_id = :entity_id`)
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, `actor = :actor`)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, `created_at >= :from`)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, `created_at < :to`)
+	}
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// addAuditEvent records the change in the transaction that makes it, so the event is kept only if the change is.
+// A nil state is stored as null
+func addAuditEvent(tx *sqlx.Tx, actor models.Actor, action string, entityType string, entityId int,
+	before json.RawMessage, after json.RawMessage) error {
+	query := fmt.Sprintf(`INSERT INTO %s (actor, action, entity_type, entity_id, request_id, before, after)
+								VALUES ($1, $2, $3, $4, $5, $6, $7)`, auditEventsTable)
+	_, err := tx.Exec(query, actor.Name, action, entityType, entityId, actor.RequestId,
+		nullableJSON(before), nullableJSON(after))
+	if err != nil {
+		return errors2.NewMusicLibraryError(errors2.InternalError, fmt.Errorf("failed add audit event: %w", err))
+	}
+	return nil
+}
+
+// songAuditState is the state of a song in the audit log
+type songAuditState struct {
+	Name        string         `json:"name" db:"name"`
+	Link        string         `json:"link" db:"link"`
+	ReleaseDate *time.Time     `json:"releaseDate" db:"release_date"`
+	Version     int            `json:"version" db:"version"`
+	DeletedAt   *time.Time     `json:"deletedAt,omitempty" db:"deleted_at"`
+	Groups      []string       `json:"groups"`
+	Verses      []models.Verse `json:"verses"`
+}
+
+// songState returns the state of the song in the transaction, songs in the trash included
+func songState(tx *sqlx.Tx, id int) (json.RawMessage, error) {
+	querySong := fmt.Sprintf(`SELECT name, link, release_date, version, deleted_at FROM %s WHERE id = $1`, songsTable)
+	var state songAuditState
+	if err := tx.Get(&state, querySong, id); err != nil {
+		return nil, errors2.NewMusicLibraryError(errors2.InternalError, fmt.Errorf("failed get song state: %w", err))
+	}
+
+	queryGroups := fmt.Sprintf(`SELECT g.name FROM %s sg JOIN %s g ON g.id = sg.group_id
+									WHERE sg.song_id = $1 ORDER BY g.name`, songsGroupsTable, groupsTable)
+	state.Groups = []string{}
+	if err := tx.Select(&state.Groups, queryGroups, id); err != nil {
+		return nil, errors2.NewMusicLibraryError(errors2.InternalError, fmt.Errorf("failed get song groups: %w", err))
+	}
+
+	anchor := fmt.Sprintf(`SELECT %s, 1 AS position
+								FROM %s v
+										 INNER JOIN %s s ON v.id = s.first_verse_id
+								WHERE s.id = $1`, verseChainColumns, versesTable, songsTable)
+	state.Verses = []models.Verse{}
+	if err := tx.Select(&state.Verses, verseChainQuery(anchor, ""), id); err != nil {
+		return nil, errors2.NewMusicLibraryError(errors2.InternalError, fmt.Errorf("failed get song verses: %w", err))
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, errors2.NewMusicLibraryError(errors2.InternalError, err)
+	}
+	return data, nil
+}
+
+// inAuditedTransaction runs fn in a transaction and records the change of the entity in the audit log as made
+// by the actor, with the states of the entity before and after fn. An added entity has no state before,
+// so its id is 0 and fn returns the id it gets, otherwise fn returns id.
+// Errors returned by fn are returned as they are. The id of the entity is returned
+func inAuditedTransaction(db *sqlx.DB, op string, actor models.Actor, action string, entityType string, id int,
+	state func(tx *sqlx.Tx, id int) (json.RawMessage, error), fn func(tx *sqlx.Tx) (int, error)) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
+	}
+	defer tx.Rollback()
+
+	var before json.RawMessage
+	if id != 0 {
+		if before, err = state(tx, id); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if id, err = fn(tx); err != nil {
+		return 0, err
+	}
+
+	after, err := state(tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = addAuditEvent(tx, actor, action, entityType, id, before, after); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+	return id, nil
+}
+
+// groupState returns the state of the group with the ids of its songs and locks it, nil if it does not exist
+func groupState(tx *sqlx.Tx, id int) (json.RawMessage, error) {
+	query := fmt.Sprintf(`SELECT jsonb_build_object(
+									'name', g.name,
+									'songIds', COALESCE((SELECT jsonb_agg(sg.song_id ORDER BY sg.song_id)
+														FROM %s sg WHERE sg.group_id = g.id), '[]'))
+								FROM %s g WHERE g.id = $1 FOR UPDATE`, songsGroupsTable, groupsTable)
+	return entityState(tx, query, id)
+}
+
+// albumState returns the state of the album with its tracks and locks it, nil if it does not exist
+func albumState(tx *sqlx.Tx, id int) (json.RawMessage, error) {
+	query := fmt.Sprintf(`SELECT jsonb_build_object(
+									'name', a.name,
+									'releaseDate', a.release_date,
+									'tracks', COALESCE((SELECT jsonb_agg(jsonb_build_object(
+															'discNumber', t.disc_number,
+															'trackNumber', t.track_number,
+															'songId', t.song_id) ORDER BY t.disc_number, t.track_number)
+														FROM %s t WHERE t.album_id = a.id), '[]'))
+								FROM %s a WHERE a.id = $1 FOR UPDATE`, albumTracksTable, albumsTable)
+	return entityState(tx, query, id)
+}
+
+// playlistState returns the state of the playlist with its items and locks it, nil if it does not exist
+func playlistState(tx *sqlx.Tx, id int) (json.RawMessage, error) {
+	query := fmt.Sprintf(`SELECT jsonb_build_object(
+									'name', p.name,
+									'items', COALESCE((SELECT jsonb_agg(jsonb_build_object(
+															'itemId', i.id,
+															'position', i.position,
+															'songId', i.song_id) ORDER BY i.position)
+														FROM %s i WHERE i.playlist_id = p.id), '[]'))
+								FROM %s p WHERE p.id = $1 FOR UPDATE`, playlistItemsTable, playlistsTable)
+	return entityState(tx, query, id)
+}
+
+// userState returns the state of the user without the password hash and locks it, nil if it does not exist
+func userState(tx *sqlx.Tx, id int) (json.RawMessage, error) {
+	query := fmt.Sprintf(`SELECT jsonb_build_object('username', username, 'role', role)
+								FROM %s WHERE id = $1 FOR UPDATE`, usersTable)
+	return entityState(tx, query, id)
+}
+
+// apiKeyState returns the state of the API key without its hash and locks it, nil if it does not exist
+func apiKeyState(tx *sqlx.Tx, id int) (json.RawMessage, error) {
+	query := fmt.Sprintf(`SELECT jsonb_build_object(
+									'name', name,
+									'prefix', prefix,
+									'scopes', scopes,
+									'createdBy', created_by,
+									'expiresAt', expires_at,
+									'revokedAt', revoked_at)
+								FROM %s WHERE id = $1 FOR UPDATE`, apiKeysTable)
+	return entityState(tx, query, id)
+}
+
+// entityState returns the state selected by the query of the entity with the id, nil if it does not exist
+func entityState(tx *sqlx.Tx, query string, id int) (json.RawMessage, error) {
+	var state []byte
+	if err := tx.Get(&state, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors2.NewMusicLibraryError(errors2.InternalError, fmt.Errorf("failed get state: %w", err))
+	}
+	return state, nil
+}
+
+// nullableJSON turns a missing state into NULL instead of an empty JSON value
+func nullableJSON(data json.RawMessage) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"strings"
+)
+
+// sortKey is one key of a keyset-paginated ordering. The id of the row is always used as
+// the last key, so it is not described by a sortKey
+type sortKey struct {
+	expression string
+	sqlType    string
+	desc       bool
+}
+
+func orderByClause(keys []sortKey, idColumn string) string {
+	parts := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		if key.desc {
+			parts = append(parts, key.expression+" DESC")
+		} else {
+			parts = append(parts, key.expression)
+		}
+	}
+	return strings.Join(append(parts, idColumn), ", ")
+}
+
+// cursorKeysColumn returns an expression that serializes the sort keys of a row into a JSON array
+func cursorKeysColumn(keys []sortKey) string {
+	expressions := make([]string, 0, len(keys))
+	for _, key := range keys {
+		expressions = append(expressions, key.expression)
+	}
+	return fmt.Sprintf(`CAST(json_build_array(%s) AS TEXT)`, strings.Join(expressions, ", "))
+}
+
+// seekCondition builds a condition selecting the rows that come after the cursor in the given ordering.
+// Cursor values are added to args as named parameters
+func seekCondition(keys []sortKey, idColumn string, cursor *models.Cursor, args map[string]interface{}) string {
+	var alternatives []string
+	var equalities []string
+	for i, key := range keys {
+		param := fmt.Sprintf("CAST(:cursor_%d AS %s)", i, key.sqlType)
+		args[fmt.Sprintf("cursor_%d", i)] = cursor.Keys[i]
+
+		operator := ">"
+		if key.desc {
+			operator = "<"
+		}
+		alternatives = append(alternatives,
+			strings.Join(append(equalities[:len(equalities):len(equalities)], key.expression+" "+operator+" "+param), " AND "))
+		equalities = append(equalities, key.expression+" = "+param)
+	}
+	args["cursor_id"] = cursor.Id
+	alternatives = append(alternatives, strings.Join(append(equalities, idColumn+" > :cursor_id"), " AND "))
+
+	return "((" + strings.Join(alternatives, ") OR (") + "))"
+}
+
+// parseCursorKeys converts a JSON array made by cursorKeysColumn into cursor keys
+func parseCursorKeys(keysJSON string) ([]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(keysJSON), &raw); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(raw))
+	for _, value := range raw {
+		var key string
+		if err := json.Unmarshal(value, &key); err != nil {
+			key = string(value)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
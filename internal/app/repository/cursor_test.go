@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseCursorKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keysJSON string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "no keys", keysJSON: `[]`, want: []string{}},
+		{name: "strings", keysJSON: `["Muse", "Uprising"]`, want: []string{"Muse", "Uprising"}},
+		{name: "numbers", keysJSON: `[0.42, 7]`, want: []string{"0.42", "7"}},
+		{name: "timestamp", keysJSON: `["2006-07-03T00:00:00"]`, want: []string{"2006-07-03T00:00:00"}},
+		{name: "escaped string", keysJSON: `["say \"hi\""]`, want: []string{`say "hi"`}},
+		{name: "not an array", keysJSON: `{"k": 1}`, wantErr: true},
+		{name: "broken", keysJSON: `["Muse"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCursorKeys(tt.keysJSON)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCursorKeys(%s) error = %v, wantErr %v", tt.keysJSON, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCursorKeys(%s) = %q, want %q", tt.keysJSON, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeekCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []sortKey
+		cursor   models.Cursor
+		want     string
+		wantArgs map[string]interface{}
+	}{
+		{
+			name:     "id only",
+			cursor:   models.Cursor{Id: 12},
+			want:     `((s.id > :cursor_id))`,
+			wantArgs: map[string]interface{}{"cursor_id": 12},
+		},
+		{
+			name:   "ascending key",
+			keys:   []sortKey{{expression: "s.name", sqlType: "TEXT"}},
+			cursor: models.Cursor{Keys: []string{"Uprising"}, Id: 12},
+			want: `((s.name > CAST(:cursor_0 AS TEXT)) OR ` +
+				`(s.name = CAST(:cursor_0 AS TEXT) AND s.id > :cursor_id))`,
+			wantArgs: map[string]interface{}{"cursor_0": "Uprising", "cursor_id": 12},
+		},
+		{
+			name: "descending and ascending keys",
+			keys: []sortKey{
+				{expression: "s.release_date", sqlType: "TIMESTAMP", desc: true},
+				{expression: "s.name", sqlType: "TEXT"},
+			},
+			cursor: models.Cursor{Keys: []string{"2009-09-14T00:00:00", "Uprising"}, Id: 12},
+			want: `((s.release_date < CAST(:cursor_0 AS TIMESTAMP)) OR ` +
+				`(s.release_date = CAST(:cursor_0 AS TIMESTAMP) AND s.name > CAST(:cursor_1 AS TEXT)) OR ` +
+				`(s.release_date = CAST(:cursor_0 AS TIMESTAMP) AND s.name = CAST(:cursor_1 AS TEXT) AND s.id > :cursor_id))`,
+			wantArgs: map[string]interface{}{"cursor_0": "2009-09-14T00:00:00", "cursor_1": "Uprising", "cursor_id": 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			got := seekCondition(tt.keys, "s.id", &tt.cursor, args)
+			if got != tt.want {
+				t.Errorf("seekCondition() = %s\nwant %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("seekCondition() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+type GroupRepository struct {
+	db *sqlx.DB
+}
+
+func NewGroupRepository(db *sqlx.DB) *GroupRepository {
+	return &GroupRepository{
+		db: db,
+	}
+}
+
+func (g *GroupRepository) GetGroups(limit int, offset int, searchText string) ([]models.Group, error) {
+	const op = "repository.group.GetGroups"
+	query := fmt.Sprintf(`SELECT id AS group_id, name AS group_name
+								FROM %s
+								WHERE $1 = '' OR name ILIKE '%%' || $1 || '%%'
+								ORDER BY name, id
+								LIMIT $2 OFFSET $3`, groupsTable)
+
+	groups := []models.Group{}
+	err := g.db.Select(&groups, query, searchText, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return groups, nil
+}
+
+func (g *GroupRepository) GetGroup(id int) (models.Group, error) {
+	const op = "repository.group.GetGroup"
+	query := fmt.Sprintf(`SELECT id AS group_id, name AS group_name FROM %s WHERE id = $1`, groupsTable)
+
+	var group models.Group
+	err := g.db.Get(&group, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, err)
+			return models.Group{}, fmt.Errorf("%s (group %d): %w", op, id, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.Group{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return group, nil
+}
+
+func (g *GroupRepository) GetGroupSongs(id int) ([]models.SongDBFormat, error) {
+	const op = "repository.group.GetGroupSongs"
+	query := fmt.Sprintf(`
+		SELECT
+			s.id, s.name, s.link, COALESCE(s.release_date, '0001-01-01') AS release_date,
+			g.id AS group_id, g.name AS group_name
+		FROM %s s
+		JOIN %s sg ON s.id = sg.song_id
+		JOIN %s g ON sg.group_id = g.id
+		WHERE s.id IN (SELECT song_id FROM %s WHERE group_id = $1) AND s.deleted_at IS NULL
+		ORDER BY s.release_date, s.id, g.id`, songsTable, songsGroupsTable, groupsTable, songsGroupsTable)
+
+	var songsData []models.SongDBFormat
+	err := g.db.Select(&songsData, query, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return songsData, nil
+}
+
+func (g *GroupRepository) RenameGroup(id int, newName string, actor models.Actor) error {
+	const op = "repository.group.RenameGroup"
+	queryCheckName := fmt.Sprintf(`SELECT COALESCE((SELECT id FROM %s WHERE name = $1 AND id <> $2), 0) AS id`,
+		groupsTable)
+
+	var existingId int
+	err := g.db.Get(&existingId, queryCheckName, newName, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed check group name): %w", op, mlErr)
+	}
+	if existingId != 0 {
+		mlErr := errors2.NewMusicLibraryError(errors2.ConflictError,
+			fmt.Errorf("group %d already has name %q, merge the groups instead", existingId, newName))
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	_, err = inAuditedTransaction(g.db, op, actor, models.AuditActionChange, models.AuditEntityGroup, id, groupState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`UPDATE %s SET name = $1 WHERE id = $2`, groupsTable)
+			res, err := tx.Exec(query, newName, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			if err = checkAffected(op, res, "group", id); err != nil {
+				return 0, err
+			}
+
+			if err = refreshGroupSearchVectors(tx, id); err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s (failed refresh search vectors): %w", op, mlErr)
+			}
+			return id, nil
+		})
+	return err
+}
+
+// MergeGroups moves the songs of the merged groups to the group and deletes the merged groups.
+// Every group gets a merge event in the audit log, the state of a merged group after it
+// is the state of the group it is merged into
+func (g *GroupRepository) MergeGroups(id int, mergedIds []int, actor models.Actor) error {
+	const op = "repository.group.MergeGroups"
+	tx, err := g.db.Beginx()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
+	}
+	defer tx.Rollback()
+
+	queryMoveRelations := fmt.Sprintf(`INSERT INTO %s (song_id, group_id)
+											SELECT DISTINCT song_id, $1 FROM %s
+											WHERE group_id = $2
+											AND song_id NOT IN (SELECT song_id FROM %s WHERE group_id = $1)`,
+		songsGroupsTable, songsGroupsTable, songsGroupsTable)
+	queryDeleteGroup := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, groupsTable)
+
+	groupIds := append([]int{id}, mergedIds...)
+	before := make([]json.RawMessage, len(groupIds))
+	for i, groupId := range groupIds {
+		// the state also locks the group
+		if before[i], err = groupState(tx, groupId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if before[i] == nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("group %d does not exist", groupId))
+			return fmt.Errorf("%s: %w", op, mlErr)
+		}
+	}
+
+	for _, mergedId := range mergedIds {
+		_, err = tx.Exec(queryMoveRelations, id, mergedId)
+		if err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("%s (failed move relations): %w", op, mlErr)
+		}
+		_, err = tx.Exec(queryDeleteGroup, mergedId)
+		if err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("%s (failed delete merged group): %w", op, mlErr)
+		}
+	}
+
+	queryDeleteDuplicates := fmt.Sprintf(`DELETE FROM %s a USING %s b
+												WHERE a.ctid < b.ctid
+												AND a.song_id = b.song_id
+												AND a.group_id = b.group_id
+												AND a.group_id = $1`, songsGroupsTable, songsGroupsTable)
+	_, err = tx.Exec(queryDeleteDuplicates, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed delete duplicate relations): %w", op, mlErr)
+	}
+
+	if err = refreshGroupSearchVectors(tx, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed refresh search vectors): %w", op, mlErr)
+	}
+
+	after, err := groupState(tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for i, groupId := range groupIds {
+		err = addAuditEvent(tx, actor, models.AuditActionMerge, models.AuditEntityGroup, groupId, before[i], after)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+	return nil
+}
+
+func (g *GroupRepository) DeleteGroup(id int, actor models.Actor) error {
+	const op = "repository.group.DeleteGroup"
+	_, err := inAuditedTransaction(g.db, op, actor, models.AuditActionDelete, models.AuditEntityGroup, id, groupState,
+		func(tx *sqlx.Tx) (int, error) {
+			queryCountSongs := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE group_id = $1`, songsGroupsTable)
+
+			var songsCount int
+			err := tx.Get(&songsCount, queryCountSongs, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s (failed count songs): %w", op, mlErr)
+			}
+			if songsCount != 0 {
+				mlErr := errors2.NewMusicLibraryError(errors2.ConflictError,
+					fmt.Errorf("group %d still has %d songs, merge it into another group instead", id, songsCount))
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+
+			query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, groupsTable)
+			res, err := tx.Exec(query, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "group", id)
+		})
+	return err
+}
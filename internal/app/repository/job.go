@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"time"
+)
+
+const jobColumns = `id, song_id, group_name, song_name, status, attempts, last_error, run_at, created_at, updated_at`
+
+type JobRepository struct {
+	db *sqlx.DB
+}
+
+func NewJobRepository(db *sqlx.DB) *JobRepository {
+	return &JobRepository{
+		db: db,
+	}
+}
+
+// AddSongWithJob adds a draft song with only its name and groups and a job to fill in its metadata,
+// which is looked up by the first group. If the song of the first group is already in the library,
+// its id is returned without a job. The added song is recorded in the audit log as added by the actor
+func (j *JobRepository) AddSongWithJob(groups []string, song string, actor models.Actor) (int, int, error) {
+	const op = "repository.job.AddSongWithJob"
+	tx, err := j.db.Beginx()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, 0, fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
+	}
+	defer tx.Rollback()
+
+	songId, err := findSong(tx, groups[0], song)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, 0, fmt.Errorf("%s (failed get song id): %w", op, mlErr)
+	}
+	if songId != 0 {
+		return songId, 0, nil
+	}
+
+	songId, err = insertSong(tx, groups, song, nil, nil, "")
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (song_id, group_name, song_name) VALUES ($1, $2, $3) RETURNING id`,
+		enrichmentJobsTable)
+	var jobId int
+	if err = tx.Get(&jobId, query, songId, groups[0], song); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, 0, fmt.Errorf("%s (failed insert job): %w", op, mlErr)
+	}
+
+	after, err := songState(tx, songId)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = addAuditEvent(tx, actor, models.AuditActionAdd, models.AuditEntitySong, songId, nil, after); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, 0, fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+	return songId, jobId, nil
+}
+
+// ClaimJobs marks up to limit due jobs as running for the lease and returns them. A running job
+// whose lease is over, e.g. because its worker died, is claimed again. Jobs claimed by concurrent
+// callers are skipped, so every job is given to one caller only
+func (j *JobRepository) ClaimJobs(limit int, lease time.Duration) ([]models.Job, error) {
+	const op = "repository.job.ClaimJobs"
+	query := fmt.Sprintf(`UPDATE %s
+								SET status = $1, attempts = attempts + 1, updated_at = now(),
+									locked_until = now() + CAST($2 AS INTEGER) * INTERVAL '1 second'
+								WHERE id IN (
+									SELECT id FROM %s
+									WHERE (status = $3 AND run_at <= now()) OR (status = $1 AND locked_until < now())
+									ORDER BY run_at, id
+									LIMIT $4
+									FOR UPDATE SKIP LOCKED
+								)
+								RETURNING %s`, enrichmentJobsTable, enrichmentJobsTable, jobColumns)
+
+	jobs := []models.Job{}
+	err := j.db.Select(&jobs, query, models.JobStatusRunning, int(lease.Seconds()), models.JobStatusPending, limit)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return jobs, nil
+}
+
+// CompleteJob marks the job as done
+func (j *JobRepository) CompleteJob(id int) error {
+	const op = "repository.job.CompleteJob"
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, last_error = NULL, locked_until = NULL, updated_at = now()
+								WHERE id = $2`, enrichmentJobsTable)
+	res, err := j.db.Exec(query, models.JobStatusDone, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return checkAffected(op, res, "job", id)
+}
+
+// FailJob records the error of the job. The job is run again at retryAt if it is set, otherwise it is failed
+func (j *JobRepository) FailJob(id int, jobErr string, retryAt *time.Time) error {
+	const op = "repository.job.FailJob"
+	status := models.JobStatusFailed
+	if retryAt != nil {
+		status = models.JobStatusPending
+	}
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, last_error = $2, run_at = COALESCE($3, run_at),
+									locked_until = NULL, updated_at = now()
+								WHERE id = $4`, enrichmentJobsTable)
+	res, err := j.db.Exec(query, status, jobErr, retryAt, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return checkAffected(op, res, "job", id)
+}
+
+func (j *JobRepository) GetJob(id int) (models.Job, error) {
+	const op = "repository.job.GetJob"
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, jobColumns, enrichmentJobsTable)
+
+	var job models.Job
+	err := j.db.Get(&job, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, err)
+			return models.Job{}, fmt.Errorf("%s (job %d): %w", op, id, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.Job{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return job, nil
+}
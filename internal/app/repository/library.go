@@ -7,7 +7,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	errors2 "github.com/nosikmy/music-library/internal/app/errors"
 	"github.com/nosikmy/music-library/internal/app/models"
-	"time"
+	"strings"
 )
 
 type LibraryRepository struct {
@@ -20,64 +20,321 @@ func NewLibraryRepository(db *sqlx.DB) *LibraryRepository {
 	}
 }
 
-func (l *LibraryRepository) GetLibrary(limit, offset int, searchText string, dateFrom, dateTo time.Time) ([]models.SongDBFormat, error) {
+// GetLibrary returns song×group rows of a page of songs, the total number of matching songs and
+// the cursor of the next page (nil on the last page). If cursor is set, the page starts right after it
+// and offset is ignored
+func (l *LibraryRepository) GetLibrary(limit, offset int, filter models.LibraryFilter,
+	cursor *models.Cursor) ([]models.SongDBFormat, int, *models.Cursor, error) {
 	const op = "repository.library.GetLibrary"
-	query := fmt.Sprintf(`
-		SELECT
-			s.id, s.name, s.link, s.release_date,
-			g.id AS group_id, g.name AS group_name
-		FROM %s s
-		JOIN %s sg ON s.id = sg.song_id
-		JOIN %s g ON sg.group_id = g.id `, songsTable, songsGroupsTable, groupsTable)
-	if searchText != "" || !dateFrom.IsZero() || !dateTo.IsZero() {
-		query += " WHERE "
-	}
-	args := 0
-	if searchText != "" {
-		query += `(s.name ILIKE '%' || :search_text || '%' OR g.name ILIKE '%' || :search_text || '%') `
-		args++
-	}
-	if !dateFrom.IsZero() {
-		if args > 0 {
-			query += "AND "
-		}
-		query += `s.release_date >= :start_date `
-		args++
-	}
-	if !dateTo.IsZero() {
-		if args > 0 {
-			query += "AND "
-		}
-		query += `s.release_date <= :end_date `
+	where, scoreColumn := libraryConditions(filter)
+	keys, err := librarySortKeys(filter)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%s: %w", op, err)
 	}
-	query += `LIMIT :limit OFFSET :offset`
 
 	filters := map[string]interface{}{
-		"search_text": searchText,
-		"start_date":  dateFrom,
-		"end_date":    dateTo,
-		"limit":       limit,
+		"search_text": filter.SearchText,
+		"start_date":  filter.DateFrom,
+		"end_date":    filter.DateTo,
+		"album_id":    filter.AlbumId,
+		"limit":       limit + 1,
 		"offset":      offset,
 	}
 
+	queryCount := fmt.Sprintf(`SELECT COUNT(*) FROM %s s %s`, songsTable, where)
+	queryCount, args, err := sqlx.Named(queryCount, filters)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, 0, nil, fmt.Errorf("%s (failed bind count query): %w", op, mlErr)
+	}
+	var total int
+	if err = l.db.Get(&total, l.db.Rebind(queryCount), args...); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, 0, nil, fmt.Errorf("%s (failed count songs): %w", op, mlErr)
+	}
+
+	seek := ""
+	if cursor != nil {
+		if len(cursor.Keys) != len(keys) {
+			mlErr := errors2.NewMusicLibraryError(errors2.BadRequestError,
+				fmt.Errorf("cursor does not match the ordering of the library"))
+			return nil, 0, nil, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		seek = "WHERE " + seekCondition(keys, "s.id", cursor, filters)
+		filters["offset"] = 0
+	}
+
+	query := fmt.Sprintf(`
+		WITH page AS (
+			SELECT id, %s AS cursor_keys, score, ROW_NUMBER() OVER (ORDER BY %s) AS position
+			FROM (SELECT s.*, CAST(%s AS FLOAT8) AS score FROM %s s %s) s
+			%s
+			ORDER BY position
+			LIMIT :limit OFFSET :offset
+		)
+		SELECT
+			s.id, s.name, s.link, COALESCE(s.release_date, '0001-01-01') AS release_date, s.version, p.score, p.cursor_keys,
+			COALESCE(g.id, 0) AS group_id, COALESCE(g.name, '') AS group_name
+		FROM page p
+		JOIN %s s ON s.id = p.id
+		LEFT JOIN %s sg ON s.id = sg.song_id
+		LEFT JOIN %s g ON sg.group_id = g.id
+		ORDER BY p.position, g.id`,
+		cursorKeysColumn(keys), orderByClause(keys, "s.id"), scoreColumn, songsTable, where, seek,
+		songsTable, songsGroupsTable, groupsTable)
+
 	var songsData []models.SongDBFormat
 	rows, err := l.db.NamedQuery(query, filters)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return songsData, nil
+			return songsData, total, nil, nil
 		}
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return nil, fmt.Errorf("%s: %w", op, mlErr)
+		return nil, 0, nil, fmt.Errorf("%s: %w", op, mlErr)
 	}
 	defer rows.Close()
 
+	var nextCursor *models.Cursor
+	songsCount := 0
 	for rows.Next() {
 		var songDB models.SongDBFormat
 		if err = rows.StructScan(&songDB); err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return nil, fmt.Errorf("%s: %w", op, mlErr)
+			return nil, 0, nil, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		if len(songsData) == 0 || songsData[len(songsData)-1].Id != songDB.Id {
+			songsCount++
+		}
+		// one song more than the limit is fetched only to know if there is a next page
+		if songsCount > limit {
+			if len(songsData) > 0 {
+				last := songsData[len(songsData)-1]
+				cursorKeys, err := parseCursorKeys(last.CursorKeys)
+				if err != nil {
+					mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+					return nil, 0, nil, fmt.Errorf("%s (failed parse cursor keys): %w", op, mlErr)
+				}
+				nextCursor = &models.Cursor{Keys: cursorKeys, Id: last.Id}
+			}
+			break
 		}
 		songsData = append(songsData, songDB)
 	}
+	return songsData, total, nextCursor, nil
+}
+
+// GetExportBatch returns song×group rows of up to limit songs matching the filter with ids greater than afterId,
+// ordered by id. The sorting of the filter is ignored
+func (l *LibraryRepository) GetExportBatch(filter models.LibraryFilter, afterId int,
+	limit int) ([]models.SongDBFormat, error) {
+	const op = "repository.library.GetExportBatch"
+	where, _ := libraryConditions(filter)
+	query := fmt.Sprintf(`
+		WITH batch AS (
+			SELECT s.id FROM %s s
+			%s AND s.id > :after_id
+			ORDER BY s.id
+			LIMIT :limit
+		)
+		SELECT
+			s.id, s.name, s.link, COALESCE(s.release_date, '0001-01-01') AS release_date,
+			COALESCE(g.id, 0) AS group_id, COALESCE(g.name, '') AS group_name
+		FROM batch b
+		JOIN %s s ON s.id = b.id
+		LEFT JOIN %s sg ON s.id = sg.song_id
+		LEFT JOIN %s g ON sg.group_id = g.id
+		ORDER BY s.id, g.id`, songsTable, where, songsTable, songsGroupsTable, groupsTable)
+
+	query, args, err := sqlx.Named(query, map[string]interface{}{
+		"search_text": filter.SearchText,
+		"start_date":  filter.DateFrom,
+		"end_date":    filter.DateTo,
+		"album_id":    filter.AlbumId,
+		"after_id":    afterId,
+		"limit":       limit,
+	})
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s (failed bind query): %w", op, mlErr)
+	}
+
+	var songsData []models.SongDBFormat
+	if err = l.db.Select(&songsData, l.db.Rebind(query), args...); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
 	return songsData, nil
 }
+
+// GetVersesOfSongs returns the verses of every song in order, walking all the chains at once
+func (l *LibraryRepository) GetVersesOfSongs(songIds []int) (map[int][]models.Verse, error) {
+	const op = "repository.library.GetVersesOfSongs"
+	verses := make(map[int][]models.Verse, len(songIds))
+	if len(songIds) == 0 {
+		return verses, nil
+	}
+	query, args, err := sqlx.In(fmt.Sprintf(`WITH RECURSIVE verse_chain AS (
+								SELECT s.id AS song_id, %s, 1 AS position
+								FROM %s v
+										 INNER JOIN %s s ON v.id = s.first_verse_id
+								WHERE s.id IN (?)
+							
+								UNION ALL
+							
+								SELECT vc.song_id, %s, vc.position + 1
+								FROM %s v
+										 INNER JOIN verse_chain vc ON v.id = vc.next
+							)
+							SELECT vc.song_id, vc.id, vc.kind, COALESCE(vc.label, o.label, '') AS label, vc.repeat_of,
+								   COALESCE(o.text, vc.text, '') AS text,
+								   vc.start_ms, vc.end_ms, vc.line_starts_ms
+							FROM verse_chain vc
+									 LEFT JOIN %s o ON o.id = vc.repeat_of
+							ORDER BY vc.song_id, vc.position`,
+		verseChainColumns, versesTable, songsTable, verseChainColumns, versesTable, versesTable), songIds)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s (failed bind query): %w", op, mlErr)
+	}
+
+	var rows []struct {
+		SongId int `db:"song_id"`
+		models.Verse
+	}
+	if err = l.db.Select(&rows, l.db.Rebind(query), args...); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	for _, row := range rows {
+		verses[row.SongId] = append(verses[row.SongId], row.Verse)
+	}
+	return verses, nil
+}
+
+// librarySortKeys maps the requested sort fields onto SQL expressions over the filtered songs
+// subquery aliased as s. Without sort fields songs are ordered by id, or by score in fuzzy mode
+func librarySortKeys(filter models.LibraryFilter) ([]sortKey, error) {
+	if len(filter.Sort) == 0 {
+		if filter.Fuzzy && filter.SearchText != "" {
+			return []sortKey{{expression: "s.score", sqlType: "FLOAT8", desc: true}}, nil
+		}
+		return nil, nil
+	}
+
+	keys := make([]sortKey, 0, len(filter.Sort))
+	for _, field := range filter.Sort {
+		key, ok := librarySortExpressions[field.Field]
+		if !ok {
+			mlErr := errors2.NewMusicLibraryError(errors2.BadRequestError,
+				fmt.Errorf("unknown sort field %q", field.Field))
+			return nil, mlErr
+		}
+		key.desc = field.Desc
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+var librarySortExpressions = map[string]sortKey{
+	"id":          {expression: "s.id", sqlType: "INTEGER"},
+	"name":        {expression: "COALESCE(s.name, '')", sqlType: "TEXT"},
+	"releaseDate": {expression: "COALESCE(s.release_date, '-infinity')", sqlType: "TIMESTAMP"},
+	"groupName": {
+		expression: fmt.Sprintf(`COALESCE((SELECT MIN(g.name) FROM %s sg JOIN %s g ON g.id = sg.group_id
+								WHERE sg.song_id = s.id), '')`, songsGroupsTable, groupsTable),
+		sqlType: "TEXT",
+	},
+	"score": {expression: "s.score", sqlType: "FLOAT8"},
+}
+
+// libraryConditions builds the WHERE clause over songs aliased as s and the expression of
+// the fuzzy search score (constant 0 outside of fuzzy mode). Group names are matched through
+// EXISTS subqueries, so every song appears once no matter how many groups it has
+func libraryConditions(filter models.LibraryFilter) (string, string) {
+	songGroupNames := fmt.Sprintf(`SELECT g.name FROM %s sg JOIN %s g ON g.id = sg.group_id WHERE sg.song_id = s.id`,
+		songsGroupsTable, groupsTable)
+
+	scoreColumn := "0"
+	// songs in the trash are hidden
+	conditions := []string{`s.deleted_at IS NULL`}
+	if filter.SearchText != "" && filter.Fuzzy {
+		scoreColumn = fmt.Sprintf(`GREATEST(similarity(s.name, :search_text),
+				COALESCE((SELECT MAX(similarity(gn.name, :search_text)) FROM (%s) gn), 0))`, songGroupNames)
+		conditions = append(conditions, fmt.Sprintf(
+			`(s.name %% :search_text OR EXISTS (SELECT 1 FROM (%s) gn WHERE gn.name %% :search_text))`,
+			songGroupNames))
+	} else if filter.SearchText != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			`(s.name ILIKE '%%' || :search_text || '%%'
+				OR EXISTS (SELECT 1 FROM (%s) gn WHERE gn.name ILIKE '%%' || :search_text || '%%'))`,
+			songGroupNames))
+	}
+	if !filter.DateFrom.IsZero() {
+		conditions = append(conditions, `s.release_date >= :start_date`)
+	}
+	if !filter.DateTo.IsZero() {
+		conditions = append(conditions, `s.release_date <= :end_date`)
+	}
+	if filter.AlbumId != 0 {
+		conditions = append(conditions,
+			fmt.Sprintf(`s.id IN (SELECT song_id FROM %s WHERE album_id = :album_id)`, albumTracksTable))
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), scoreColumn
+}
+
+func (l *LibraryRepository) Search(limit, offset int, searchText string) ([]models.SearchHit, error) {
+	const op = "repository.library.Search"
+	query := fmt.Sprintf(`WITH RECURSIVE search_query AS (
+								SELECT websearch_to_tsquery($4::regconfig, $1) AS q
+							), hits AS (
+								SELECT s.id, s.name, s.first_verse_id, ts_rank(s.search_vector, sq.q) AS rank
+								FROM %s s, search_query sq
+								WHERE s.search_vector @@ sq.q AND s.deleted_at IS NULL
+								ORDER BY rank DESC, s.id
+								LIMIT $2 OFFSET $3
+							), verse_chain AS (
+								SELECT h.id AS song_id, v.id, v.text, v.next
+								FROM %s v
+										 INNER JOIN hits h ON v.id = h.first_verse_id
+
+								UNION ALL
+
+								SELECT vc.song_id, v.id, v.text, v.next
+								FROM %s v
+										 INNER JOIN verse_chain vc ON v.id = vc.next
+							), best_verses AS (
+								SELECT DISTINCT ON (vc.song_id) vc.song_id, vc.id, vc.text
+								FROM verse_chain vc, search_query sq
+								WHERE to_tsvector($4::regconfig, COALESCE(vc.text, '')) @@ sq.q
+								ORDER BY vc.song_id, ts_rank(to_tsvector($4::regconfig, COALESCE(vc.text, '')), sq.q) DESC, vc.id
+							)
+							SELECT h.id AS song_id, h.name, h.rank, bv.id AS verse_id,
+								   ts_headline($4::regconfig, COALESCE(bv.text, h.name), sq.q,
+											   'StartSel=<b>, StopSel=</b>, MaxFragments=2') AS snippet
+							FROM hits h
+									 LEFT JOIN best_verses bv ON bv.song_id = h.id,
+								 search_query sq
+							ORDER BY h.rank DESC, h.id`, songsTable, versesTable, versesTable)
+
+	hits := []models.SearchHit{}
+	err := l.db.Select(&hits, query, searchText, limit, offset, searchConfig)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return hits, nil
+}
+
+// refreshSearchVector rebuilds the full-text search vector of a song from its name, groups and verses
+func refreshSearchVector(e sqlx.Execer, songId int) error {
+	_, err := e.Exec(`SELECT refresh_song_search_vector($1)`, songId)
+	return err
+}
+
+// refreshGroupSearchVectors rebuilds the full-text search vectors of every song of a group
+func refreshGroupSearchVectors(e sqlx.Execer, groupId int) error {
+	query := fmt.Sprintf(`SELECT refresh_song_search_vector(song_id) FROM %s WHERE group_id = $1`, songsGroupsTable)
+	_, err := e.Exec(query, groupId)
+	return err
+}
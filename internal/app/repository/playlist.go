@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+type PlaylistRepository struct {
+	db *sqlx.DB
+}
+
+func NewPlaylistRepository(db *sqlx.DB) *PlaylistRepository {
+	return &PlaylistRepository{
+		db: db,
+	}
+}
+
+var playlistColumns = fmt.Sprintf(`p.id, p.name, p.created_at, p.updated_at,
+								(SELECT COUNT(*) FROM %s pi WHERE pi.playlist_id = p.id) AS items_count`, playlistItemsTable)
+
+func (p *PlaylistRepository) GetPlaylists(limit int, offset int) ([]models.Playlist, error) {
+	const op = "repository.playlist.GetPlaylists"
+	query := fmt.Sprintf(`SELECT %s FROM %s p ORDER BY p.id LIMIT $1 OFFSET $2`, playlistColumns, playlistsTable)
+
+	playlists := []models.Playlist{}
+	err := p.db.Select(&playlists, query, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return playlists, nil
+}
+
+func (p *PlaylistRepository) GetPlaylist(id int) (models.Playlist, error) {
+	const op = "repository.playlist.GetPlaylist"
+	query := fmt.Sprintf(`SELECT %s FROM %s p WHERE p.id = $1`, playlistColumns, playlistsTable)
+
+	var playlist models.Playlist
+	err := p.db.Get(&playlist, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("playlist %d does not exist", id))
+			return models.Playlist{}, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.Playlist{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return playlist, nil
+}
+
+// GetPlaylistItems returns a page of the items of the playlist in order, a row per item and group of its song
+func (p *PlaylistRepository) GetPlaylistItems(id int, limit int, offset int) ([]models.PlaylistItemDBFormat, error) {
+	const op = "repository.playlist.GetPlaylistItems"
+	query := fmt.Sprintf(`
+		SELECT
+			pi.id AS item_id, pi.position,
+			s.id, s.name, s.link, COALESCE(s.release_date, '0001-01-01') AS release_date,
+			s.version, s.created_at, s.updated_at,
+			COALESCE(g.id, 0) AS group_id, COALESCE(g.name, '') AS group_name
+		FROM (SELECT * FROM %s WHERE playlist_id = $1 ORDER BY position LIMIT $2 OFFSET $3) pi
+		JOIN %s s ON s.id = pi.song_id
+		LEFT JOIN %s sg ON s.id = sg.song_id
+		LEFT JOIN %s g ON sg.group_id = g.id
+		ORDER BY pi.position, g.id`, playlistItemsTable, songsTable, songsGroupsTable, groupsTable)
+
+	var items []models.PlaylistItemDBFormat
+	err := p.db.Select(&items, query, id, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return items, nil
+}
+
+func (p *PlaylistRepository) AddPlaylist(name string, actor models.Actor) (int, error) {
+	const op = "repository.playlist.AddPlaylist"
+	return inAuditedTransaction(p.db, op, actor, models.AuditActionAdd, models.AuditEntityPlaylist, 0, playlistState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) RETURNING id`, playlistsTable)
+
+			var id int
+			err := tx.Get(&id, query, name)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, nil
+		})
+}
+
+func (p *PlaylistRepository) RenamePlaylist(id int, name string, actor models.Actor) error {
+	const op = "repository.playlist.RenamePlaylist"
+	_, err := inAuditedTransaction(p.db, op, actor, models.AuditActionChange, models.AuditEntityPlaylist, id,
+		playlistState, func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`UPDATE %s SET name = $1, updated_at = now() WHERE id = $2`, playlistsTable)
+
+			res, err := tx.Exec(query, name, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "playlist", id)
+		})
+	return err
+}
+
+func (p *PlaylistRepository) DeletePlaylist(id int, actor models.Actor) error {
+	const op = "repository.playlist.DeletePlaylist"
+	_, err := inAuditedTransaction(p.db, op, actor, models.AuditActionDelete, models.AuditEntityPlaylist, id,
+		playlistState, func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, playlistsTable)
+
+			res, err := tx.Exec(query, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "playlist", id)
+		})
+	return err
+}
+
+// AddItem puts the song at the position of the playlist and returns the id of the item.
+// The items from the position on move down, position 0 or a position past the end appends the song
+func (p *PlaylistRepository) AddItem(id int, songId int, position int, actor models.Actor) (int, error) {
+	const op = "repository.playlist.AddItem"
+	var itemId int
+	err := inPlaylistTransaction(p.db, id, actor, func(tx *sqlx.Tx, count int) error {
+		if position == 0 || position > count {
+			position = count + 1
+		}
+		queryShift := fmt.Sprintf(`UPDATE %s SET position = position + 1 WHERE playlist_id = $1 AND position >= $2`,
+			playlistItemsTable)
+		if _, err := tx.Exec(queryShift, id, position); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed shift items: %w", mlErr)
+		}
+
+		queryInsert := fmt.Sprintf(`INSERT INTO %s (playlist_id, song_id, position)
+										SELECT $1, id, $3 FROM %s WHERE id = $2 AND deleted_at IS NULL
+										RETURNING id`, playlistItemsTable, songsTable)
+		err := tx.Get(&itemId, queryInsert, id, songId, position)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("song %d does not exist", songId))
+			}
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed insert item: %w", mlErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return itemId, nil
+}
+
+// MoveItem moves the item to the position of the playlist, a position past the end moves it to the end
+func (p *PlaylistRepository) MoveItem(id int, itemId int, position int, actor models.Actor) error {
+	const op = "repository.playlist.MoveItem"
+	err := inPlaylistTransaction(p.db, id, actor, func(tx *sqlx.Tx, count int) error {
+		current, err := itemPosition(tx, id, itemId)
+		if err != nil {
+			return err
+		}
+		position = min(position, count)
+		if position == current {
+			return nil
+		}
+
+		queryShift := fmt.Sprintf(`UPDATE %s SET position = position + 1
+										WHERE playlist_id = $1 AND position >= $2 AND position < $3`, playlistItemsTable)
+		from, to := position, current
+		if position > current {
+			queryShift = fmt.Sprintf(`UPDATE %s SET position = position - 1
+										WHERE playlist_id = $1 AND position > $2 AND position <= $3`, playlistItemsTable)
+			from, to = current, position
+		}
+		if _, err = tx.Exec(queryShift, id, from, to); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed shift items: %w", mlErr)
+		}
+
+		queryMove := fmt.Sprintf(`UPDATE %s SET position = $1 WHERE id = $2`, playlistItemsTable)
+		if _, err = tx.Exec(queryMove, position, itemId); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed move item: %w", mlErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteItem removes the item from the playlist, the items after it move up
+func (p *PlaylistRepository) DeleteItem(id int, itemId int, actor models.Actor) error {
+	const op = "repository.playlist.DeleteItem"
+	err := inPlaylistTransaction(p.db, id, actor, func(tx *sqlx.Tx, count int) error {
+		position, err := itemPosition(tx, id, itemId)
+		if err != nil {
+			return err
+		}
+
+		queryDelete := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, playlistItemsTable)
+		if _, err = tx.Exec(queryDelete, itemId); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed delete item: %w", mlErr)
+		}
+
+		queryShift := fmt.Sprintf(`UPDATE %s SET position = position - 1 WHERE playlist_id = $1 AND position > $2`,
+			playlistItemsTable)
+		if _, err = tx.Exec(queryShift, id, position); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed shift items: %w", mlErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// inPlaylistTransaction locks the playlist, so its items are changed by one transaction at a time,
+// runs fn with the number of items and marks the playlist as updated
+func inPlaylistTransaction(db *sqlx.DB, id int, actor models.Actor, fn func(tx *sqlx.Tx, count int) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed begin transaction: %w", mlErr)
+	}
+	defer tx.Rollback()
+
+	// the state also locks the playlist
+	before, err := playlistState(tx, id)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("playlist %d does not exist", id))
+	}
+
+	queryCount := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE playlist_id = $1`, playlistItemsTable)
+	var count int
+	if err = tx.Get(&count, queryCount, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed count items: %w", mlErr)
+	}
+
+	if err = fn(tx, count); err != nil {
+		return err
+	}
+
+	queryTouch := fmt.Sprintf(`UPDATE %s SET updated_at = now() WHERE id = $1`, playlistsTable)
+	if _, err = tx.Exec(queryTouch, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed touch playlist: %w", mlErr)
+	}
+
+	after, err := playlistState(tx, id)
+	if err != nil {
+		return err
+	}
+	if err = addAuditEvent(tx, actor, models.AuditActionChange, models.AuditEntityPlaylist, id, before, after); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed to commit: %w", mlErr)
+	}
+	return nil
+}
+
+func itemPosition(tx *sqlx.Tx, id int, itemId int) (int, error) {
+	query := fmt.Sprintf(`SELECT position FROM %s WHERE id = $1 AND playlist_id = $2`, playlistItemsTable)
+	var position int
+	err := tx.Get(&position, query, itemId, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors2.NewMusicLibraryError(errors2.NotFoundError,
+				fmt.Errorf("item %d is not in playlist %d", itemId, id))
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("failed get item position: %w", mlErr)
+	}
+	return position, nil
+}
+
+// removeSongFromPlaylists removes the song from all playlists closing the gaps it leaves
+func removeSongFromPlaylists(tx *sqlx.Tx, songId int) error {
+	queryDelete := fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1 RETURNING playlist_id`, playlistItemsTable)
+	var playlistIds []int
+	if err := tx.Select(&playlistIds, queryDelete, songId); err != nil {
+		return fmt.Errorf("failed delete items: %w", err)
+	}
+	if len(playlistIds) == 0 {
+		return nil
+	}
+
+	queryRenumber, args, err := sqlx.In(fmt.Sprintf(`UPDATE %s pi SET position = numbered.position
+								FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY playlist_id ORDER BY position) AS position
+									  FROM %s WHERE playlist_id IN (?)) numbered
+								WHERE pi.id = numbered.id AND pi.position <> numbered.position`,
+		playlistItemsTable, playlistItemsTable), playlistIds)
+	if err != nil {
+		return fmt.Errorf("failed bind renumber query: %w", err)
+	}
+	if _, err = tx.Exec(tx.Rebind(queryRenumber), args...); err != nil {
+		return fmt.Errorf("failed renumber items: %w", err)
+	}
+
+	queryTouch, args, err := sqlx.In(fmt.Sprintf(`UPDATE %s SET updated_at = now() WHERE id IN (?)`, playlistsTable),
+		playlistIds)
+	if err != nil {
+		return fmt.Errorf("failed bind touch query: %w", err)
+	}
+	if _, err = tx.Exec(tx.Rebind(queryTouch), args...); err != nil {
+		return fmt.Errorf("failed touch playlists: %w", err)
+	}
+	return nil
+}
@@ -1,15 +1,33 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 const (
-	songsTable       = "songs"
-	groupsTable      = "groups"
-	songsGroupsTable = "songs_groups"
-	versesTable      = "verses"
+	songsTable          = "songs"
+	groupsTable         = "groups"
+	songsGroupsTable    = "songs_groups"
+	versesTable         = "verses"
+	albumsTable         = "albums"
+	albumTracksTable    = "album_tracks"
+	songRevisionsTable  = "song_revisions"
+	enrichmentJobsTable = "enrichment_jobs"
+	playlistsTable      = "playlists"
+	playlistItemsTable  = "playlist_items"
+	usersTable          = "users"
+	apiKeysTable        = "api_keys"
+	auditEventsTable    = "audit_events"
+
+	searchConfig = "simple"
+
+	// foreignKeyViolation is the Postgres error code of a reference to a missing row
+	foreignKeyViolation = "23503"
+	// uniqueViolation is the Postgres error code of a duplicate key
+	uniqueViolation = "23505"
 )
 
 type Config struct {
@@ -35,3 +53,18 @@ func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// violatedForeignKey returns the constraint of the foreign key the error violates, if it is such an error
+func violatedForeignKey(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
+
+// violatedUnique tells whether the error is a duplicate key of a unique constraint
+func violatedUnique(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+type RevisionRepository struct {
+	db *sqlx.DB
+}
+
+func NewRevisionRepository(db *sqlx.DB) *RevisionRepository {
+	return &RevisionRepository{
+		db: db,
+	}
+}
+
+// GetRevisions returns the revisions of the song, the latest first
+func (r *RevisionRepository) GetRevisions(songId int, limit int, offset int) ([]models.Revision, error) {
+	const op = "repository.revision.GetRevisions"
+	query := fmt.Sprintf(`SELECT revision, song_id, editor, action, created_at, before, after
+								FROM %s
+								WHERE song_id = $1
+								ORDER BY revision DESC
+								LIMIT $2 OFFSET $3`, songRevisionsTable)
+
+	revisions := []models.Revision{}
+	err := r.db.Select(&revisions, query, songId, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return revisions, nil
+}
+
+func (r *RevisionRepository) GetRevision(songId int, revision int) (models.Revision, error) {
+	const op = "repository.revision.GetRevision"
+	query := fmt.Sprintf(`SELECT revision, song_id, editor, action, created_at, before, after
+								FROM %s
+								WHERE song_id = $1 AND revision = $2`, songRevisionsTable)
+
+	var rev models.Revision
+	err := r.db.Get(&rev, query, songId, revision)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, err)
+			return models.Revision{}, fmt.Errorf("%s (revision %d of song %d): %w", op, revision, songId, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.Revision{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return rev, nil
+}
+
+// addRevision records the change of the locked song in the transaction that makes it and returns the number
+// of the revision. Revisions are numbered per song starting from 1
+func addRevision(tx *sqlx.Tx, songId int, editor string, action string,
+	before models.SongSnapshot, after models.SongSnapshot) (int, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (song_id, revision, editor, action, before, after)
+								SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5
+								FROM %s
+								WHERE song_id = $1
+								RETURNING revision`, songRevisionsTable, songRevisionsTable)
+	var revision int
+	if err := tx.Get(&revision, query, songId, editor, action, before, after); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("failed insert revision: %w", mlErr)
+	}
+	return revision, nil
+}
+
+// songSnapshot returns the name, groups and verses of the song in the transaction
+func songSnapshot(tx *sqlx.Tx, id int) (models.SongSnapshot, error) {
+	snapshot := models.SongSnapshot{
+		Groups: []models.Group{},
+		Verses: []models.Verse{},
+	}
+	queryName := fmt.Sprintf(`SELECT name FROM %s WHERE id = $1`, songsTable)
+	if err := tx.Get(&snapshot.Name, queryName, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.SongSnapshot{}, fmt.Errorf("failed get song name: %w", mlErr)
+	}
+
+	queryGroups := fmt.Sprintf(`SELECT g.id AS group_id, g.name AS group_name
+									FROM %s sg JOIN %s g ON g.id = sg.group_id
+									WHERE sg.song_id = $1 ORDER BY g.id`, songsGroupsTable, groupsTable)
+	if err := tx.Select(&snapshot.Groups, queryGroups, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.SongSnapshot{}, fmt.Errorf("failed get song groups: %w", mlErr)
+	}
+
+	anchor := fmt.Sprintf(`SELECT %s, 1 AS position
+								FROM %s v
+										 INNER JOIN %s s ON v.id = s.first_verse_id
+								WHERE s.id = $1`, verseChainColumns, versesTable, songsTable)
+	if err := tx.Select(&snapshot.Verses, verseChainQuery(anchor, ""), id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.SongSnapshot{}, fmt.Errorf("failed get song verses: %w", mlErr)
+	}
+	return snapshot, nil
+}
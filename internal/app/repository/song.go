@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	errors2 "github.com/nosikmy/music-library/internal/app/errors"
@@ -18,133 +20,360 @@ func NewSongRepository(db *sqlx.DB) *SongRepository {
 	}
 }
 
-func (s *SongRepository) GetSongText(id int, limit int, offset int) (int, []models.Verse, error) {
+// GetSongText returns a page of verses of the song and the cursor of the next page (nil on the last page).
+// If cursor is set, the chain is walked from the verse following the cursor verse and offset is ignored
+func (s *SongRepository) GetSongText(id int, limit int, offset int, cursor *models.Cursor) (int, []models.Verse, *models.Cursor, error) {
 	const op = "repository.song.GetSongText"
-	query := fmt.Sprintf(`WITH RECURSIVE verse_chain AS (
-								SELECT v.id, v.text, v.next
+	anchor := fmt.Sprintf(`SELECT %s, 1 AS position
 								FROM %s v
 										 INNER JOIN %s s ON v.id = s.first_verse_id
-								WHERE s.id = $1
-							
-								UNION ALL
-							
-								SELECT v.id, v.text, v.next
+								WHERE s.id = $1 AND s.deleted_at IS NULL`, verseChainColumns, versesTable, songsTable)
+	args := []interface{}{id, limit + 1, offset}
+	if cursor != nil {
+		anchor = fmt.Sprintf(`SELECT %s, 1 AS position
 								FROM %s v
-										 INNER JOIN verse_chain vc ON v.id = vc.next
-							)
-							SELECT id, text
-							FROM verse_chain
-							LIMIT $2 OFFSET $3`, versesTable, songsTable, versesTable)
+										 INNER JOIN %s prev ON v.id = prev.next
+								WHERE prev.id = $4
+								AND EXISTS (SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)`, verseChainColumns, versesTable, versesTable, songsTable)
+		args = []interface{}{id, limit + 1, 0, cursor.Id}
+	}
+	query := verseChainQuery(anchor, "LIMIT $2 OFFSET $3")
 
 	var text []models.Verse
-	err := s.db.Select(&text, query, id, limit, offset)
+	err := s.db.Select(&text, query, args...)
 
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return 0, nil, fmt.Errorf("%s: %w", op, mlErr)
+		return 0, nil, nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	// one verse more than the limit is fetched only to know if there is a next page
+	var nextCursor *models.Cursor
+	if len(text) > limit {
+		text = text[:limit]
+		if limit > 0 {
+			nextCursor = &models.Cursor{Id: text[limit-1].Id}
+		}
+	}
+	return len(text), text, nextCursor, nil
+
+}
+
+// GetSongVerses returns all verses of the song in order
+func (s *SongRepository) GetSongVerses(id int) ([]models.Verse, error) {
+	const op = "repository.song.GetSongVerses"
+	anchor := fmt.Sprintf(`SELECT %s, 1 AS position
+								FROM %s v
+										 INNER JOIN %s s ON v.id = s.first_verse_id
+								WHERE s.id = $1 AND s.deleted_at IS NULL`, verseChainColumns, versesTable, songsTable)
+
+	verses := []models.Verse{}
+	err := s.db.Select(&verses, verseChainQuery(anchor, ""), id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return verses, nil
+}
+
+// GetSong returns the song with one row per group of the song
+func (s *SongRepository) GetSong(id int) ([]models.SongDBFormat, error) {
+	const op = "repository.song.GetSong"
+	query := fmt.Sprintf(`
+		SELECT
+			s.id, s.name, s.link, COALESCE(s.release_date, '0001-01-01') AS release_date,
+			s.version, s.created_at, s.updated_at,
+			COALESCE(g.id, 0) AS group_id, COALESCE(g.name, '') AS group_name
+		FROM %s s
+		LEFT JOIN %s sg ON s.id = sg.song_id
+		LEFT JOIN %s g ON sg.group_id = g.id
+		WHERE s.id = $1 AND s.deleted_at IS NULL
+		ORDER BY g.id`, songsTable, songsGroupsTable, groupsTable)
+
+	var songData []models.SongDBFormat
+	err := s.db.Select(&songData, query, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	if len(songData) == 0 {
+		mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("song %d not found", id))
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return songData, nil
+}
+
+// GetSongVersion returns the version of the song that is not in the trash
+func (s *SongRepository) GetSongVersion(id int) (int, error) {
+	const op = "repository.song.GetSongVersion"
+	query := fmt.Sprintf(`SELECT version FROM %s WHERE id = $1 AND deleted_at IS NULL`, songsTable)
+
+	var version int
+	err := s.db.Get(&version, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, err)
+			return 0, fmt.Errorf("%s (song %d): %w", op, id, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
 	}
-	return len(text), text, nil
+	return version, nil
+}
 
+// ImportVerses replaces the whole verse chain of the song with the imported verses.
+// If version is not 0, the song must have this version. The change is recorded as an import revision.
+// The new version of the song is returned
+func (s *SongRepository) ImportVerses(id int, version int, verses []models.VerseDraft, actor models.Actor) (int, error) {
+	const op = "repository.song.ImportVerses"
+	newVersion, _, err := inSongTransaction(s.db, op, id, version, actor, models.RevisionActionImport,
+		func(tx *sqlx.Tx) error {
+			return replaceVerses(tx, id, verses)
+		})
+	return newVersion, err
 }
 
-func (s *SongRepository) DeleteSong(id int) error {
+// DeleteSong moves the song to the trash, it can be restored or purged from there.
+// The song is removed from all playlists. If version is not 0, the song must have this version
+func (s *SongRepository) DeleteSong(id int, version int, actor models.Actor) error {
 	const op = "repository.song.DeleteSong"
 	tx, err := s.db.Beginx()
 	if err != nil {
-		return err
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
 	}
 	defer tx.Rollback()
 
-	queryGetLastVerseId := fmt.Sprintf(`SELECT last_verse_id FROM %s WHERE id = $1`, songsTable)
-	var verseId int
-	err = s.db.Get(&verseId, queryGetLastVerseId, id)
+	if err = lockSong(tx, id, version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	before, err := songState(tx, id)
 	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = now(), version = version + 1, updated_at = now() WHERE id = $1`, songsTable)
+	if _, err = tx.Exec(query, id); err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed get verse id): %w", op, mlErr)
+		return fmt.Errorf("%s: %w", op, mlErr)
 	}
 
-	queryDeleteVerses := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, versesTable)
-	queryDeleteSong := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, songsTable)
-	_, err = tx.Exec(queryDeleteSong, id)
-	if err != nil {
+	if err = removeSongFromPlaylists(tx, id); err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed delete song): %w", op, mlErr)
+		return fmt.Errorf("%s: %w", op, mlErr)
 	}
-	_, err = tx.Exec(queryDeleteVerses, verseId)
+
+	after, err := songState(tx, id)
 	if err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed delete verses): %w", op, mlErr)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = addAuditEvent(tx, actor, models.AuditActionDelete, models.AuditEntitySong, id, before, after); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err = tx.Commit(); err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed commit): %w", op, mlErr)
+		return fmt.Errorf("%s (failed to commit): %w", op, mlErr)
 	}
-
 	return nil
 }
 
-func (s *SongRepository) AddSong(group string, song string, releaseDate time.Time, verses []string, link string) (int, error) {
+// AddSong adds the song of the groups and returns its id. If the song of the first group, or a song
+// without groups if there are none, is already in the library, its id is returned and false tells
+// that nothing was added. The release date may be nil
+func (s *SongRepository) AddSong(groups []string, song string, releaseDate *time.Time, verses []models.VerseDraft,
+	link string, actor models.Actor) (int, bool, error) {
 	const op = "repository.song.AddSong"
-	queryCheckSongExist := fmt.Sprintf(`SELECT COALESCE((SELECT s.id FROM %s s
-                								JOIN %s sg on s.id = sg.song_id
-												JOIN %s g on g.id = sg.group_id
-												WHERE s.name = $1 and g.name = $2), 0) AS id`,
-		songsTable, songsGroupsTable, groupsTable)
 	var songId int
-	err := s.db.Get(&songId, queryCheckSongExist, song, group)
+	var err error
+	if len(groups) == 0 {
+		songId, err = findSongWithoutGroups(s.db, song)
+	} else {
+		songId, err = findSong(s.db, groups[0], song)
+	}
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return 0, fmt.Errorf("%s (failed get song id): %w", op, mlErr)
+		return 0, false, fmt.Errorf("%s (failed get song id): %w", op, mlErr)
 	}
 	if songId != 0 {
-		return songId, nil
+		return songId, false, nil
 	}
 
 	tx, err := s.db.Beginx()
 	if err != nil {
-		return 0, err
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, false, fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
 	}
 	defer tx.Rollback()
+
+	songId, err = insertSong(tx, groups, song, releaseDate, verses, link)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	after, err := songState(tx, songId)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = addAuditEvent(tx, actor, models.AuditActionAdd, models.AuditEntitySong, songId, nil, after); err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, false, fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+
+	return songId, true, nil
+}
+
+// findSong returns the id of the song of the group that is not in the trash, 0 if there is no such song
+func findSong(q sqlx.Queryer, group string, song string) (int, error) {
+	queryCheckSongExist := fmt.Sprintf(`SELECT COALESCE((SELECT s.id FROM %s s
+                								JOIN %s sg on s.id = sg.song_id
+												JOIN %s g on g.id = sg.group_id
+												WHERE s.name = $1 and g.name = $2 AND s.deleted_at IS NULL
+												ORDER BY s.id
+												LIMIT 1), 0) AS id`,
+		songsTable, songsGroupsTable, groupsTable)
+	var songId int
+	err := sqlx.Get(q, &songId, queryCheckSongExist, song, group)
+	return songId, err
+}
+
+// findSongWithoutGroups returns the id of the song without groups that is not in the trash,
+// 0 if there is no such song
+func findSongWithoutGroups(q sqlx.Queryer, song string) (int, error) {
+	query := fmt.Sprintf(`SELECT COALESCE((SELECT s.id FROM %s s
+												WHERE s.name = $1 AND s.deleted_at IS NULL
+													AND NOT EXISTS (SELECT 1 FROM %s sg WHERE sg.song_id = s.id)
+												ORDER BY s.id
+												LIMIT 1), 0) AS id`,
+		songsTable, songsGroupsTable)
+	var songId int
+	err := sqlx.Get(q, &songId, query, song)
+	return songId, err
+}
+
+// insertSong inserts the song of the groups with its verses. A song without a release date
+// and verses is a draft waiting for its metadata
+func insertSong(tx *sqlx.Tx, groups []string, song string, releaseDate *time.Time, verses []models.VerseDraft,
+	link string) (int, error) {
 	firstVerseId, lastVerseId, err := insertVerses(tx, verses)
 	if err != nil {
-		return 0, err
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("failed insert verses: %w", mlErr)
 	}
 
 	queryInsertSong := fmt.Sprintf(`INSERT INTO %s (name, link, release_date, first_verse_id, last_verse_id)
-											VALUES ($1, $2, $3, $4, $5)
+											VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0))
 											RETURNING id;`, songsTable)
+	var songId int
 	err = tx.Get(&songId, queryInsertSong, song, link, releaseDate, firstVerseId, lastVerseId)
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return 0, fmt.Errorf("%s (failed insert song): %w", op, mlErr)
+		return 0, fmt.Errorf("failed insert song: %w", mlErr)
 	}
 
-	groupId, err := addGroup(tx, group)
-	if err != nil {
+	queryAddRelation := fmt.Sprintf(`INSERT INTO %s (song_id, group_id)  VALUES ($1, $2)`, songsGroupsTable)
+	for _, group := range groups {
+		groupId, err := addGroup(tx, group)
+		if err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return 0, fmt.Errorf("failed add group: %w", mlErr)
+		}
+
+		_, err = tx.Exec(queryAddRelation, songId, groupId)
+		if err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return 0, fmt.Errorf("failed add relation between song and group: %w", mlErr)
+		}
+	}
+
+	if err = refreshSearchVector(tx, songId); err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return 0, fmt.Errorf("%s (failed add group): %w", op, mlErr)
+		return 0, fmt.Errorf("failed refresh search vector: %w", mlErr)
 	}
+	return songId, nil
+}
 
-	queryAddRelation := fmt.Sprintf(`INSERT INTO %s (song_id, group_id)  VALUES ($1, $2)`, songsGroupsTable)
+// EnrichSong sets the release date, link and verses of the song, e.g. of a draft that got its metadata.
+// The new version of the song is returned
+func (s *SongRepository) EnrichSong(id int, releaseDate time.Time, link string, verses []models.VerseDraft,
+	actor models.Actor) (int, error) {
+	const op = "repository.song.EnrichSong"
+	newVersion, _, err := inSongTransaction(s.db, op, id, 0, actor, "", func(tx *sqlx.Tx) error {
+		if err := changeSongReleaseDate(tx, id, releaseDate); err != nil {
+			return err
+		}
+		if err := changeSongLink(tx, id, link); err != nil {
+			return err
+		}
+		return replaceVerses(tx, id, verses)
+	})
+	return newVersion, err
+}
 
-	_, err = tx.Exec(queryAddRelation, songId, groupId)
+// replaceVerses replaces the whole verse chain of the locked song
+func replaceVerses(tx *sqlx.Tx, id int, verses []models.VerseDraft) error {
+	queryFirstVerse := fmt.Sprintf(`SELECT first_verse_id FROM %s WHERE id = $1`, songsTable)
+	var oldFirstVerseId *int
+	err := tx.Get(&oldFirstVerseId, queryFirstVerse, id)
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return 0, fmt.Errorf("%s (failed add relation between song and group): %w", op, mlErr)
+		return fmt.Errorf("failed get first verse: %w", mlErr)
 	}
 
-	if err = tx.Commit(); err != nil {
+	firstVerseId, lastVerseId, err := insertVerses(tx, verses)
+	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return 0, fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+		return fmt.Errorf("failed insert verses: %w", mlErr)
 	}
 
-	return songId, nil
+	// the song has to point to the new chain before the old one is deleted,
+	// deleting the last verse of a song deletes the song
+	querySetVerses := fmt.Sprintf(`UPDATE %s SET first_verse_id = NULLIF($1, 0), last_verse_id = NULLIF($2, 0)
+											WHERE id = $3`, songsTable)
+	_, err = tx.Exec(querySetVerses, firstVerseId, lastVerseId, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed set verses of song: %w", mlErr)
+	}
+
+	if oldFirstVerseId != nil {
+		if err = deleteVerseChain(tx, *oldFirstVerseId); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed delete old verses: %w", mlErr)
+		}
+	}
+	return nil
 }
 
-func insertVerses(tx *sqlx.Tx, verses []string) (int, int, error) {
-	queryInsert := fmt.Sprintf(`INSERT INTO %s (text, next)
-										VALUES ($1, NULL)
+// GetSimilarGroups returns existing groups whose names look like a misspelling of the given one.
+// Nothing is returned if a group with exactly this name exists
+func (s *SongRepository) GetSimilarGroups(group string, limit int) ([]models.Group, error) {
+	const op = "repository.song.GetSimilarGroups"
+	query := fmt.Sprintf(`SELECT id AS group_id, name AS group_name
+								FROM %s
+								WHERE name %% $1
+								AND NOT EXISTS (SELECT id FROM %s WHERE name = $1)
+								ORDER BY similarity(name, $1) DESC, id
+								LIMIT $2`, groupsTable, groupsTable)
+
+	groups := []models.Group{}
+	err := s.db.Select(&groups, query, group, limit)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return groups, nil
+}
+
+// insertVerses stores the verses as a linked list and returns the ids of its first and last verses.
+// Repeated verses keep no text of their own and reference the verse they repeat
+func insertVerses(tx *sqlx.Tx, verses []models.VerseDraft) (int, int, error) {
+	queryInsert := fmt.Sprintf(`INSERT INTO %s (text, kind, label, repeat_of, start_ms, end_ms, line_starts_ms, next)
+										VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, NULL)
 										RETURNING id`, versesTable)
 	queryAddNextId := fmt.Sprintf(`UPDATE %s SET next = $1 WHERE id = $2`, versesTable)
 
@@ -163,13 +392,37 @@ func insertVerses(tx *sqlx.Tx, verses []string) (int, int, error) {
 	var prevID *int
 	var firstID int
 	var lastID int
+	// the id of the verse holding the text of every inserted verse
+	originIds := make([]int, 0, len(verses))
 
 	for i, verse := range verses {
+		kind := verse.Kind
+		if kind == "" {
+			kind = models.VerseKindVerse
+		}
+		var text *string
+		var repeatOf *int
+		if verse.Repeats != nil {
+			if *verse.Repeats < 0 || *verse.Repeats >= i {
+				return 0, 0, fmt.Errorf("verse %d repeats verse %d that is not before it", i, *verse.Repeats)
+			}
+			originId := originIds[*verse.Repeats]
+			repeatOf = &originId
+		} else {
+			text = &verse.Text
+		}
+
 		var id int
-		err = stmtInsert.QueryRow(verse).Scan(&id)
+		err = stmtInsert.QueryRow(text, kind, verse.Label, repeatOf,
+			verse.StartMs, verse.EndMs, verse.LineStartsMs).Scan(&id)
 		if err != nil {
 			return 0, 0, err
 		}
+		if repeatOf != nil {
+			originIds = append(originIds, *repeatOf)
+		} else {
+			originIds = append(originIds, id)
+		}
 
 		if i == 0 {
 			firstID = id
@@ -188,6 +441,45 @@ func insertVerses(tx *sqlx.Tx, verses []string) (int, int, error) {
 	return firstID, lastID, nil
 }
 
+// verseChainColumns are the columns of a verse walked through by verseChainQuery
+const verseChainColumns = `v.id, v.text, v.next, v.kind, v.label, v.repeat_of, v.start_ms, v.end_ms, v.line_starts_ms`
+
+// verseChainQuery walks the verse chain starting from the verses selected by anchor.
+// paging is applied to the chain before repeated verses get the text of the verse they repeat
+func verseChainQuery(anchor string, paging string) string {
+	return fmt.Sprintf(`WITH RECURSIVE verse_chain AS (
+								%s
+							
+								UNION ALL
+							
+								SELECT %s, vc.position + 1
+								FROM %s v
+										 INNER JOIN verse_chain vc ON v.id = vc.next
+							)
+							SELECT vc.id, vc.kind, COALESCE(vc.label, o.label, '') AS label, vc.repeat_of,
+								   COALESCE(o.text, vc.text, '') AS text,
+								   vc.start_ms, vc.end_ms, vc.line_starts_ms
+							FROM (SELECT * FROM verse_chain %s) vc
+									 LEFT JOIN %s o ON o.id = vc.repeat_of
+							ORDER BY vc.position`, anchor, verseChainColumns, versesTable, paging, versesTable)
+}
+
+// deleteVerseChain deletes the verse and all verses following it
+func deleteVerseChain(tx *sqlx.Tx, firstVerseId int) error {
+	query := fmt.Sprintf(`WITH RECURSIVE verse_chain AS (
+								SELECT id, next FROM %s WHERE id = $1
+							
+								UNION ALL
+							
+								SELECT v.id, v.next
+								FROM %s v
+										 INNER JOIN verse_chain vc ON v.id = vc.next
+							)
+							DELETE FROM %s WHERE id IN (SELECT id FROM verse_chain)`, versesTable, versesTable, versesTable)
+	_, err := tx.Exec(query, firstVerseId)
+	return err
+}
+
 func addGroup(tx *sqlx.Tx, groupName string) (int, error) {
 	queryInsert := fmt.Sprintf(`INSERT INTO %s (name)
 										SELECT $1
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"time"
 )
 
 type SongChangerRepository struct {
@@ -16,54 +20,225 @@ func NewSongChangerRepository(db *sqlx.DB) *SongChangerRepository {
 	}
 }
 
-func (s *SongChangerRepository) ChangeSongName(id int, newName string) error {
-	const op = "repository.song_changer.ChangeSongName"
-	query := fmt.Sprintf(`UPDATE %s SET name = $1 WHERE id = $2`, songsTable)
-	_, err := s.db.Exec(query, newName, id)
-	if err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s: %w", op, mlErr)
+// UpdateSong applies the operations in order within a single transaction: either all of them are applied or none.
+// Verses inserted after the same verse keep the order of their operations.
+// If version is not 0, the song must have this version. The change is recorded as a revision.
+// The new version of the song and the number of the revision are returned
+func (s *SongChangerRepository) UpdateSong(id int, version int, operations []models.SongOperation,
+	actor models.Actor) (int, int, error) {
+	const op = "repository.song_changer.UpdateSong"
+	return inSongTransaction(s.db, op, id, version, actor, models.RevisionActionChange, func(tx *sqlx.Tx) error {
+		return applyOperations(tx, id, operations)
+	})
+}
+
+// RevertSong applies the operations and replaces the whole verse chain of the song within a single transaction,
+// bringing the song back to an earlier state. If version is not 0, the song must have this version.
+// The change is recorded as a restore revision.
+// The new version of the song and the number of the revision are returned
+func (s *SongChangerRepository) RevertSong(id int, version int, operations []models.SongOperation,
+	verses []models.VerseDraft, actor models.Actor) (int, int, error) {
+	const op = "repository.song_changer.RevertSong"
+	return inSongTransaction(s.db, op, id, version, actor, models.RevisionActionRestore, func(tx *sqlx.Tx) error {
+		if err := applyOperations(tx, id, operations); err != nil {
+			return err
+		}
+		return replaceVerses(tx, id, verses)
+	})
+}
+
+// applyOperations applies the operations to the locked song in order
+func applyOperations(tx *sqlx.Tx, id int, operations []models.SongOperation) error {
+	// the last verse inserted after a verse by this update
+	inserted := make(map[int]int)
+	for i, operation := range operations {
+		var err error
+		switch operation.Op {
+		case models.SongOpRename:
+			err = changeSongName(tx, id, operation.Name)
+		case models.SongOpAddGroup:
+			err = addGroupToSong(tx, id, operation.Name)
+		case models.SongOpRemoveGroup:
+			err = deleteGroupFromSong(tx, id, operation.GroupId)
+		case models.SongOpSetLink:
+			err = changeSongLink(tx, id, operation.Link)
+		case models.SongOpSetReleaseDate:
+			err = changeSongReleaseDate(tx, id, operation.ReleaseDate.Time)
+		case models.SongOpInsertVerse:
+			prevId := operation.AfterVerseId
+			if lastId, ok := inserted[prevId]; ok {
+				prevId = lastId
+			}
+			var newVerseId int
+			newVerseId, err = addVerse(tx, id, prevId, operation.VerseFields())
+			inserted[operation.AfterVerseId] = newVerseId
+		case models.SongOpUpdateVerse:
+			err = changeVerse(tx, id, operation.VerseFields())
+		case models.SongOpDeleteVerse:
+			err = deleteVerse(tx, id, operation.VerseId)
+		case models.SongOpMoveVerse:
+			err = moveVerse(tx, id, operation.VerseId, operation.AfterVerseId)
+		default:
+			err = errors2.NewMusicLibraryError(errors2.BadRequestError, fmt.Errorf("unknown operation %q", operation.Op))
+		}
+		if err != nil {
+			return fmt.Errorf("operation %d (%s): %w", i, operation.Op, err)
+		}
 	}
 	return nil
 }
 
-func (s *SongChangerRepository) AddGroupToSong(id int, group string) error {
-	const op = "repository.song_changer.AddGroupToSong"
-	tx, err := s.db.Beginx()
+// inSongTransaction locks the song and runs fn in a transaction. Before committing, the search vector
+// of the song is refreshed and its version is bumped. If version is not 0, the song must have this version.
+// If revisionAction is not empty, the change is recorded as a revision of the song with this action.
+// Errors returned by fn are expected to be music library errors.
+// The new version of the song and the number of the revision, 0 if none is recorded, are returned
+func inSongTransaction(db *sqlx.DB, op string, songId int, version int, actor models.Actor, revisionAction string,
+	fn func(tx *sqlx.Tx) error) (int, int, error) {
+	tx, err := db.Beginx()
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
+		return 0, 0, fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
 	}
 	defer tx.Rollback()
 
-	groupId, err := addGroup(tx, group)
+	// the lock also keeps concurrent changes from corrupting the verse chain
+	// and from getting the same revision number
+	if err = lockSong(tx, songId, version); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	before, err := songState(tx, songId)
 	if err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed add Group): %w", op, mlErr)
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	var snapshotBefore models.SongSnapshot
+	if revisionAction != "" {
+		if snapshotBefore, err = songSnapshot(tx, songId); err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
-	queryAddRelation := fmt.Sprintf(`INSERT INTO %s (song_id, group_id)  VALUES ($1, $2)`, songsGroupsTable)
+	if err = fn(tx); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
 
-	_, err = tx.Exec(queryAddRelation, id, groupId)
+	if err = refreshSearchVector(tx, songId); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, 0, fmt.Errorf("%s (failed refresh search vector): %w", op, mlErr)
+	}
+
+	newVersion, err := bumpVersion(tx, songId)
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed add relaton beetween song and group): %w", op, mlErr)
+		return 0, 0, fmt.Errorf("%s (failed bump version): %w", op, mlErr)
+	}
+
+	after, err := songState(tx, songId)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	err = addAuditEvent(tx, actor, models.AuditActionChange, models.AuditEntitySong, songId, before, after)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var revision int
+	if revisionAction != "" {
+		snapshotAfter, err := songSnapshot(tx, songId)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		revision, err = addRevision(tx, songId, actor.Name, revisionAction, snapshotBefore, snapshotAfter)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+		return 0, 0, fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+	return newVersion, revision, nil
+}
+
+// lockSong locks the song that is not in the trash. If version is not 0, the song must have this version
+func lockSong(tx *sqlx.Tx, songId int, version int) error {
+	queryLock := fmt.Sprintf(`SELECT version FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, songsTable)
+	var currentVersion int
+	err := tx.Get(&currentVersion, queryLock, songId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("song %d does not exist", songId))
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed lock song: %w", mlErr)
+	}
+	if version != 0 && version != currentVersion {
+		return errors2.NewMusicLibraryError(errors2.PreconditionFailedError,
+			fmt.Errorf("song %d has version %d, not %d", songId, currentVersion, version))
+	}
+	return nil
+}
+
+// bumpVersion bumps the version of the song and marks it as updated now
+func bumpVersion(tx *sqlx.Tx, songId int) (int, error) {
+	query := fmt.Sprintf(`UPDATE %s SET version = version + 1, updated_at = now() WHERE id = $1 RETURNING version`, songsTable)
+	var version int
+	err := tx.Get(&version, query, songId)
+	return version, err
+}
+
+func changeSongName(tx *sqlx.Tx, id int, newName string) error {
+	query := fmt.Sprintf(`UPDATE %s SET name = $1 WHERE id = $2`, songsTable)
+	if _, err := tx.Exec(query, newName, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed change name: %w", mlErr)
 	}
 	return nil
 }
 
-func (s *SongChangerRepository) DeleteGroupFromSong(id int, groupId int) error {
-	const op = "repository.song_changer.DeleteGroupFromSong"
-	query := fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1 AND group_id = $2`, songsGroupsTable)
-	_, err := s.db.Exec(query, id, groupId)
+func changeSongLink(tx *sqlx.Tx, id int, link string) error {
+	query := fmt.Sprintf(`UPDATE %s SET link = $1 WHERE id = $2`, songsTable)
+	if _, err := tx.Exec(query, link, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed change link: %w", mlErr)
+	}
+	return nil
+}
+
+func changeSongReleaseDate(tx *sqlx.Tx, id int, releaseDate time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET release_date = $1 WHERE id = $2`, songsTable)
+	if _, err := tx.Exec(query, releaseDate, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed change release date: %w", mlErr)
+	}
+	return nil
+}
+
+func addGroupToSong(tx *sqlx.Tx, id int, group string) error {
+	groupId, err := addGroup(tx, group)
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s: %w", op, mlErr)
+		return fmt.Errorf("failed add group: %w", mlErr)
+	}
+
+	queryAddRelation := fmt.Sprintf(`INSERT INTO %s (song_id, group_id)
+											SELECT $1, $2
+											WHERE NOT EXISTS (SELECT 1 FROM %s WHERE song_id = $1 AND group_id = $2)`,
+		songsGroupsTable, songsGroupsTable)
+	if _, err = tx.Exec(queryAddRelation, id, groupId); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed add relation between song and group: %w", mlErr)
+	}
+	return nil
+}
+
+func deleteGroupFromSong(tx *sqlx.Tx, id int, groupId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1 AND group_id = $2`, songsGroupsTable)
+	if _, err := tx.Exec(query, id, groupId); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed delete relation between song and group: %w", mlErr)
 	}
 	return nil
 }
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+type TrashRepository struct {
+	db *sqlx.DB
+}
+
+func NewTrashRepository(db *sqlx.DB) *TrashRepository {
+	return &TrashRepository{
+		db: db,
+	}
+}
+
+// GetTrash returns song×group rows of a page of deleted songs, the latest deleted first
+func (t *TrashRepository) GetTrash(limit int, offset int) ([]models.SongDBFormat, error) {
+	const op = "repository.trash.GetTrash"
+	query := fmt.Sprintf(`
+		WITH page AS (
+			SELECT id FROM %s
+			WHERE deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC, id
+			LIMIT $1 OFFSET $2
+		)
+		SELECT
+			s.id, s.name, s.link, COALESCE(s.release_date, '0001-01-01') AS release_date, s.deleted_at,
+			COALESCE(g.id, 0) AS group_id, COALESCE(g.name, '') AS group_name
+		FROM page p
+		JOIN %s s ON s.id = p.id
+		LEFT JOIN %s sg ON s.id = sg.song_id
+		LEFT JOIN %s g ON sg.group_id = g.id
+		ORDER BY s.deleted_at DESC, s.id, g.id`, songsTable, songsTable, songsGroupsTable, groupsTable)
+
+	var songsData []models.SongDBFormat
+	err := t.db.Select(&songsData, query, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return songsData, nil
+}
+
+// RestoreSong takes the song out of the trash
+func (t *TrashRepository) RestoreSong(id int, actor models.Actor) error {
+	const op = "repository.trash.RestoreSong"
+	tx, err := t.db.Beginx()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
+	}
+	defer tx.Rollback()
+
+	if _, err = lockDeletedSong(tx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	duplicateId, err := liveDuplicate(tx, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed check duplicate): %w", op, mlErr)
+	}
+	if duplicateId != 0 {
+		mlErr := errors2.NewMusicLibraryError(errors2.ConflictError,
+			fmt.Errorf("song %d of the same group with the same name is in the library", duplicateId))
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+	before, err := songState(tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, version = version + 1, updated_at = now() WHERE id = $1`,
+		songsTable)
+	if _, err = tx.Exec(query, id); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	after, err := songState(tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = addAuditEvent(tx, actor, models.AuditActionRestore, models.AuditEntitySong, id, before, after); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+	return nil
+}
+
+// PurgeSong deletes the song in the trash for good together with its whole verse chain
+func (t *TrashRepository) PurgeSong(id int, actor models.Actor) error {
+	const op = "repository.trash.PurgeSong"
+	tx, err := t.db.Beginx()
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed begin transaction): %w", op, mlErr)
+	}
+	defer tx.Rollback()
+
+	firstVerseId, err := lockDeletedSong(tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// the state is taken before the song and its verses are gone
+	before, err := songState(tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the song goes first as it references its first and last verses
+	queryDeleteSong := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, songsTable)
+	_, err = tx.Exec(queryDeleteSong, id)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed delete song): %w", op, mlErr)
+	}
+	if firstVerseId != nil {
+		if err = deleteVerseChain(tx, *firstVerseId); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("%s (failed delete verses): %w", op, mlErr)
+		}
+	}
+	if err = addAuditEvent(tx, actor, models.AuditActionPurge, models.AuditEntitySong, id, before, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("%s (failed to commit): %w", op, mlErr)
+	}
+	return nil
+}
+
+// lockDeletedSong locks the song in the trash and returns its first verse
+func lockDeletedSong(tx *sqlx.Tx, id int) (*int, error) {
+	query := fmt.Sprintf(`SELECT first_verse_id FROM %s WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`, songsTable)
+	var firstVerseId *int
+	err := tx.Get(&firstVerseId, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("deleted song %d does not exist", id))
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("failed lock song: %w", mlErr)
+	}
+	return firstVerseId, nil
+}
+
+// liveDuplicate returns the id of a song not in the trash with the name and a group of the song, 0 if there is none
+func liveDuplicate(tx *sqlx.Tx, id int) (int, error) {
+	query := fmt.Sprintf(`SELECT COALESCE((SELECT s.id FROM %s s
+												JOIN %s sg ON s.id = sg.song_id
+												WHERE s.deleted_at IS NULL AND s.id <> $1
+													AND s.name = (SELECT name FROM %s WHERE id = $1)
+													AND sg.group_id IN (SELECT group_id FROM %s WHERE song_id = $1)
+												ORDER BY s.id
+												LIMIT 1), 0) AS id`,
+		songsTable, songsGroupsTable, songsTable, songsGroupsTable)
+	var duplicateId int
+	err := tx.Get(&duplicateId, query, id)
+	return duplicateId, err
+}
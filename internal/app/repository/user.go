@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	errors2 "github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserRepository(db *sqlx.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// AddUser adds the user and returns its id, the username must be free
+func (u *UserRepository) AddUser(username string, passwordHash string, actor models.Actor) (int, error) {
+	const op = "repository.user.AddUser"
+	return inAuditedTransaction(u.db, op, actor, models.AuditActionAdd, models.AuditEntityUser, 0, userState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`INSERT INTO %s (username, password_hash) VALUES ($1, $2)
+										ON CONFLICT (username) DO NOTHING
+										RETURNING id`, usersTable)
+
+			var id int
+			err := tx.Get(&id, query, username, passwordHash)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					mlErr := errors2.NewMusicLibraryError(errors2.ConflictError, fmt.Errorf("username %q is taken", username))
+					return 0, fmt.Errorf("%s: %w", op, mlErr)
+				}
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, nil
+		})
+}
+
+func (u *UserRepository) GetUser(id int) (models.User, error) {
+	const op = "repository.user.GetUser"
+	query := fmt.Sprintf(`SELECT id, username, role, password_hash, created_at FROM %s WHERE id = $1`, usersTable)
+
+	var user models.User
+	err := u.db.Get(&user, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("user %d does not exist", id))
+			return models.User{}, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.User{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return user, nil
+}
+
+func (u *UserRepository) GetUserByName(username string) (models.User, error) {
+	const op = "repository.user.GetUserByName"
+	query := fmt.Sprintf(`SELECT id, username, role, password_hash, created_at FROM %s WHERE username = $1`, usersTable)
+
+	var user models.User
+	err := u.db.Get(&user, query, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			mlErr := errors2.NewMusicLibraryError(errors2.NotFoundError, fmt.Errorf("user %q does not exist", username))
+			return models.User{}, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return models.User{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return user, nil
+}
+
+func (u *UserRepository) GetUsers(limit int, offset int) ([]models.User, error) {
+	const op = "repository.user.GetUsers"
+	query := fmt.Sprintf(`SELECT id, username, role, created_at FROM %s ORDER BY id LIMIT $1 OFFSET $2`, usersTable)
+
+	users := []models.User{}
+	err := u.db.Select(&users, query, limit, offset)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return users, nil
+}
+
+func (u *UserRepository) ChangeUserRole(id int, role string, actor models.Actor) error {
+	const op = "repository.user.ChangeUserRole"
+	_, err := inAuditedTransaction(u.db, op, actor, models.AuditActionChange, models.AuditEntityUser, id, userState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`UPDATE %s SET role = $1 WHERE id = $2`, usersTable)
+
+			res, err := tx.Exec(query, role, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "user", id)
+		})
+	return err
+}
+
+func (u *UserRepository) DeleteUser(id int, actor models.Actor) error {
+	const op = "repository.user.DeleteUser"
+	_, err := inAuditedTransaction(u.db, op, actor, models.AuditActionDelete, models.AuditEntityUser, id, userState,
+		func(tx *sqlx.Tx) (int, error) {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, usersTable)
+
+			res, err := tx.Exec(query, id)
+			if err != nil {
+				mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+				return 0, fmt.Errorf("%s: %w", op, mlErr)
+			}
+			return id, checkAffected(op, res, "user", id)
+		})
+	return err
+}
@@ -7,161 +7,217 @@ import (
 	"github.com/nosikmy/music-library/internal/app/models"
 )
 
-type VersesRepository struct {
-	db *sqlx.DB
-}
+// addVerse inserts the verse after the verse prevId, or at the beginning if prevId is 0, and returns its id
+func addVerse(tx *sqlx.Tx, songId int, prevId int, newVerse *models.Verse) (int, error) {
+	text := &newVerse.Text
+	var repeatOf *int
+	if newVerse.RepeatOf != nil {
+		originId, err := verseOrigin(tx, songId, *newVerse.RepeatOf)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get repeated verse: %w", err)
+		}
+		text, repeatOf = nil, &originId
+	}
+
+	queryAddVerse := fmt.Sprintf(`INSERT INTO %s (text, kind, label, repeat_of)
+										VALUES ($1, COALESCE(NULLIF($2, ''), (SELECT kind FROM %s WHERE id = $4), 'verse'),
+												NULLIF($3, ''), $4)
+										RETURNING id`, versesTable, versesTable)
+	var newVerseId int
+	err := tx.Get(&newVerseId, queryAddVerse, text, newVerse.Kind, newVerse.Label, repeatOf)
+	if err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return 0, fmt.Errorf("failed to add verse: %w", mlErr)
+	}
 
-func NewVersesRepository(db *sqlx.DB) *VersesRepository {
-	return &VersesRepository{
-		db: db,
+	if err = linkVerse(tx, songId, newVerseId, prevId); err != nil {
+		return 0, err
 	}
+	return newVerseId, nil
 }
 
-func (v *VersesRepository) AddVerse(id int, newVerse *models.Verse) error {
-	const op = "repository.verses.AddVerse"
-	tx, err := v.db.Beginx()
+func changeVerse(tx *sqlx.Tx, songId int, changeVerse *models.Verse) error {
+	if _, err := verseOrigin(tx, songId, changeVerse.Id); err != nil {
+		return err
+	}
+
+	var err error
+	switch {
+	case changeVerse.RepeatOf == nil:
+		query := fmt.Sprintf(`UPDATE %s SET text = COALESCE(NULLIF($1, ''), text),
+										kind = COALESCE(NULLIF($2, ''), kind),
+										label = COALESCE(NULLIF($3, ''), label),
+										repeat_of = CASE WHEN $1 = '' THEN repeat_of END
+									WHERE id = $4`, versesTable)
+		_, err = tx.Exec(query, changeVerse.Text, changeVerse.Kind, changeVerse.Label, changeVerse.Id)
+	case *changeVerse.RepeatOf == 0:
+		query := fmt.Sprintf(`UPDATE %s v SET text = COALESCE(NULLIF($1, ''), o.text, v.text),
+										kind = COALESCE(NULLIF($2, ''), v.kind),
+										label = COALESCE(NULLIF($3, ''), v.label, o.label),
+										repeat_of = NULL
+									FROM %s o
+									WHERE v.id = $4 AND o.id = COALESCE(v.repeat_of, v.id)`, versesTable, versesTable)
+		_, err = tx.Exec(query, changeVerse.Text, changeVerse.Kind, changeVerse.Label, changeVerse.Id)
+	default:
+		var originId int
+		originId, err = verseOrigin(tx, songId, *changeVerse.RepeatOf)
+		if err != nil {
+			return fmt.Errorf("failed to get repeated verse: %w", err)
+		}
+		if originId == changeVerse.Id {
+			return errors2.NewMusicLibraryError(errors2.BadRequestError,
+				fmt.Errorf("verse %d can not repeat itself", changeVerse.Id))
+		}
+		queryMoveRepeats := fmt.Sprintf(`UPDATE %s SET repeat_of = $1 WHERE repeat_of = $2`, versesTable)
+		if _, err = tx.Exec(queryMoveRepeats, originId, changeVerse.Id); err != nil {
+			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+			return fmt.Errorf("failed to move repeats: %w", mlErr)
+		}
+		query := fmt.Sprintf(`UPDATE %s SET text = NULL,
+										kind = COALESCE(NULLIF($1, ''), kind),
+										label = COALESCE(NULLIF($2, ''), label),
+										repeat_of = $3
+									WHERE id = $4`, versesTable)
+		_, err = tx.Exec(query, changeVerse.Kind, changeVerse.Label, originId, changeVerse.Id)
+	}
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed to begin transaction): %w", op, mlErr)
+		return fmt.Errorf("failed to change verse: %w", mlErr)
+	}
+	return nil
+}
+
+func deleteVerse(tx *sqlx.Tx, songId int, verseId int) error {
+	if _, err := verseOrigin(tx, songId, verseId); err != nil {
+		return err
+	}
+	if err := unlinkVerse(tx, songId, verseId); err != nil {
+		return err
+	}
+
+	if err := detachRepeats(tx, verseId); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed to detach repeats: %w", mlErr)
 	}
-	defer tx.Rollback()
 
-	var nextVerseId int
+	queryDelete := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, versesTable)
+	if _, err := tx.Exec(queryDelete, verseId); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed to delete verse: %w", mlErr)
+	}
+	return nil
+}
 
-	if newVerse.Id == 0 {
-		queryGetNext := fmt.Sprintf(`SELECT COALESCE((SELECT first_verse_id FROM %s WHERE id = $1), 0) AS next`, songsTable)
-		err = tx.Get(&nextVerseId, queryGetNext, id)
+// moveVerse moves the verse after the verse prevId, or to the beginning if prevId is 0
+func moveVerse(tx *sqlx.Tx, songId int, verseId int, prevId int) error {
+	if _, err := verseOrigin(tx, songId, verseId); err != nil {
+		return err
+	}
+	if verseId == prevId {
+		return errors2.NewMusicLibraryError(errors2.BadRequestError,
+			fmt.Errorf("verse %d can not be moved after itself", verseId))
+	}
+	if err := unlinkVerse(tx, songId, verseId); err != nil {
+		return err
+	}
+	return linkVerse(tx, songId, verseId, prevId)
+}
 
+// linkVerse puts the verse that is not in the chain after the verse prevId, or at the beginning if prevId is 0
+func linkVerse(tx *sqlx.Tx, songId int, verseId int, prevId int) error {
+	var nextId int
+	var err error
+	if prevId == 0 {
+		queryGetNext := fmt.Sprintf(`SELECT COALESCE((SELECT first_verse_id FROM %s WHERE id = $1), 0) AS next`, songsTable)
+		err = tx.Get(&nextId, queryGetNext, songId)
 	} else {
+		if _, err = verseOrigin(tx, songId, prevId); err != nil {
+			return fmt.Errorf("failed to get previous verse: %w", err)
+		}
 		queryGetNext := fmt.Sprintf(`SELECT COALESCE((SELECT next FROM %s WHERE id = $1), 0) AS next`, versesTable)
-		err = tx.Get(&nextVerseId, queryGetNext, newVerse.Id)
+		err = tx.Get(&nextId, queryGetNext, prevId)
 	}
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed to get next verse id): %w", op, mlErr)
+		return fmt.Errorf("failed to get next verse id: %w", mlErr)
 	}
 
-	var newVerseId int
-	if nextVerseId == 0 {
-		queryAddVerse := fmt.Sprintf(`INSERT INTO %s (text, next) VALUES ($1, null) RETURNING id`, versesTable)
-		err = tx.Get(&newVerseId, queryAddVerse, newVerse.Text)
-		if err != nil {
-			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to add new verse to end): %w", op, mlErr)
-		}
-		err = addLastVerseToSong(tx, id, newVerseId)
-		if err != nil {
-			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to update song last verse id): %w", op, mlErr)
-		}
-	} else {
-		queryAddVerse := fmt.Sprintf(`INSERT INTO %s (text, next) VALUES ($1, $2) RETURNING id`, versesTable)
-		err = tx.Get(&newVerseId, queryAddVerse, newVerse.Text, nextVerseId)
-		if err != nil {
+	querySetNext := fmt.Sprintf(`UPDATE %s SET next = NULLIF($1, 0) WHERE id = $2`, versesTable)
+	if _, err = tx.Exec(querySetNext, nextId, verseId); err != nil {
+		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
+		return fmt.Errorf("failed to update next field for verse: %w", mlErr)
+	}
+	if nextId == 0 {
+		if err = addLastVerseToSong(tx, songId, verseId); err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to add verse): %w", op, mlErr)
+			return fmt.Errorf("failed to update song last verse id: %w", mlErr)
 		}
 	}
-
-	if newVerse.Id == 0 {
-		err = addFirstVerseToSong(tx, id, newVerseId)
+	if prevId == 0 {
+		err = addFirstVerseToSong(tx, songId, verseId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to update song first verse id): %w", op, mlErr)
+			return fmt.Errorf("failed to update song first verse id: %w", mlErr)
 		}
 	} else {
-		err = addNextVerse(tx, newVerseId, newVerse.Id)
+		err = addNextVerse(tx, verseId, prevId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to update next field for previous verse): %w", op, mlErr)
+			return fmt.Errorf("failed to update next field for previous verse: %w", mlErr)
 		}
 	}
-	if err = tx.Commit(); err != nil {
-		return err
-	}
 	return nil
 }
 
-func (v *VersesRepository) ChangeVerse(changeVerse *models.Verse) error {
-	const op = "repository.verses.ChangeVerse"
-	query := fmt.Sprintf(`UPDATE %s SET text = $1 WHERE id = $2`, versesTable)
-	_, err := v.db.Exec(query, changeVerse.Text, changeVerse.Id)
-	if err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s: %w", op, mlErr)
-	}
-	return nil
-}
-
-func (v *VersesRepository) DeleteVerse(id int, verseId int) error {
-	const op = "repository.verses.DeleteVerse"
-	tx, err := v.db.Beginx()
-	if err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed to begin transaction): %w", op, mlErr)
-	}
-	defer tx.Rollback()
-
+// unlinkVerse takes the verse out of the chain, joining its previous and next verses
+func unlinkVerse(tx *sqlx.Tx, songId int, verseId int) error {
 	queryGetNext := fmt.Sprintf(`SELECT COALESCE((SELECT next FROM %s WHERE id = $1), 0) AS next`, versesTable)
 	queryGetPrev := fmt.Sprintf(`SELECT COALESCE((SELECT id FROM %s WHERE next = $1), 0) AS next`, versesTable)
 
 	var nextId, prevId int
-	err = tx.Get(&nextId, queryGetNext, verseId)
+	err := tx.Get(&nextId, queryGetNext, verseId)
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed get next verse): %w", op, mlErr)
+		return fmt.Errorf("failed get next verse: %w", mlErr)
 	}
 	err = tx.Get(&prevId, queryGetPrev, verseId)
 	if err != nil {
 		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed get previous verse): %w", op, mlErr)
+		return fmt.Errorf("failed get previous verse: %w", mlErr)
 	}
 
 	if nextId == 0 && prevId == 0 {
 		queryChangeSong := fmt.Sprintf(`UPDATE %s SET first_verse_id = null, last_verse_id = null WHERE id = $1`, songsTable)
-		_, err = tx.Exec(queryChangeSong, id)
+		_, err = tx.Exec(queryChangeSong, songId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed update song first and last verse id): %w", op, mlErr)
+			return fmt.Errorf("failed update song first and last verse id: %w", mlErr)
 		}
 	} else if nextId == 0 {
 		queryNextNull := fmt.Sprintf(`UPDATE %s SET next = null WHERE id = $1`, versesTable)
 		_, err = tx.Exec(queryNextNull, prevId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to add verse in the end): %w", op, mlErr)
+			return fmt.Errorf("failed to make previous verse the last one: %w", mlErr)
 		}
-		err = addLastVerseToSong(tx, id, prevId)
+		err = addLastVerseToSong(tx, songId, prevId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed update song name): %w", op, mlErr)
+			return fmt.Errorf("failed update song last verse id: %w", mlErr)
 		}
 	} else if prevId == 0 {
-		err = addFirstVerseToSong(tx, id, nextId)
+		err = addFirstVerseToSong(tx, songId, nextId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed tu update song first verse id): %w", op, mlErr)
+			return fmt.Errorf("failed to update song first verse id: %w", mlErr)
 		}
 	} else {
 		err = addNextVerse(tx, nextId, prevId)
 		if err != nil {
 			mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-			return fmt.Errorf("%s (failed to update next field for previous verse): %w", op, mlErr)
+			return fmt.Errorf("failed to update next field for previous verse: %w", mlErr)
 		}
 	}
-
-	queryDelete := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, versesTable)
-	_, err = tx.Exec(queryDelete, verseId)
-	if err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed to delete verse): %w", op, mlErr)
-	}
-
-	if err = tx.Commit(); err != nil {
-		mlErr := errors2.NewMusicLibraryError(errors2.InternalError, err)
-		return fmt.Errorf("%s (failed to commit): %w", op, mlErr)
-	}
-
 	return nil
 }
 
@@ -182,3 +238,55 @@ func addNextVerse(tx *sqlx.Tx, nextId int, verseId int) error {
 	_, err := tx.Exec(query, nextId, verseId)
 	return err
 }
+
+// verseOrigin checks that the verse is in the chain of the song and returns the id
+// of the verse holding its text: the verse itself or the verse it repeats
+func verseOrigin(tx *sqlx.Tx, songId int, verseId int) (int, error) {
+	query := fmt.Sprintf(`WITH RECURSIVE verse_chain AS (
+								SELECT v.id, v.next, v.repeat_of
+								FROM %s v
+										 INNER JOIN %s s ON v.id = s.first_verse_id
+								WHERE s.id = $1
+
+								UNION ALL
+
+								SELECT v.id, v.next, v.repeat_of
+								FROM %s v
+										 INNER JOIN verse_chain vc ON v.id = vc.next
+							)
+							SELECT COALESCE((SELECT COALESCE(repeat_of, id) FROM verse_chain WHERE id = $2), 0)`,
+		versesTable, songsTable, versesTable)
+
+	var originId int
+	if err := tx.Get(&originId, query, songId, verseId); err != nil {
+		return 0, errors2.NewMusicLibraryError(errors2.InternalError, err)
+	}
+	if originId == 0 {
+		return 0, errors2.NewMusicLibraryError(errors2.BadRequestError,
+			fmt.Errorf("verse %d is not a verse of song %d", verseId, songId))
+	}
+	return originId, nil
+}
+
+// detachRepeats prepares the verse for deletion: the first verse repeating it takes over its text
+// and the other repeats are pointed to that verse
+func detachRepeats(tx *sqlx.Tx, verseId int) error {
+	queryHeir := fmt.Sprintf(`SELECT COALESCE(MIN(id), 0) FROM %s WHERE repeat_of = $1`, versesTable)
+	var heirId int
+	if err := tx.Get(&heirId, queryHeir, verseId); err != nil {
+		return err
+	}
+	if heirId == 0 {
+		return nil
+	}
+
+	queryTakeText := fmt.Sprintf(`UPDATE %s h SET text = o.text, label = COALESCE(h.label, o.label), repeat_of = NULL
+										FROM %s o
+										WHERE h.id = $1 AND o.id = $2`, versesTable, versesTable)
+	if _, err := tx.Exec(queryTakeText, heirId, verseId); err != nil {
+		return err
+	}
+	queryMoveRepeats := fmt.Sprintf(`UPDATE %s SET repeat_of = $1 WHERE repeat_of = $2`, versesTable)
+	_, err := tx.Exec(queryMoveRepeats, heirId, verseId)
+	return err
+}
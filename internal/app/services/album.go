@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"time"
+)
+
+type AlbumRepository interface {
+	GetAlbums(limit int, offset int) ([]models.Album, error)
+	GetAlbum(id int) (models.Album, error)
+	AddAlbum(name string, releaseDate time.Time, actor models.Actor) (int, error)
+	ChangeAlbum(id int, name string, releaseDate time.Time, actor models.Actor) error
+	DeleteAlbum(id int, actor models.Actor) error
+	AddTrack(albumId int, track models.AlbumTrack, actor models.Actor) error
+	DeleteTrack(albumId int, songId int, actor models.Actor) error
+}
+
+type AlbumService struct {
+	logger          *slog.Logger
+	albumRepository AlbumRepository
+}
+
+func NewAlbumService(logger *slog.Logger, a AlbumRepository) *AlbumService {
+	return &AlbumService{
+		logger:          logger,
+		albumRepository: a,
+	}
+}
+
+func (a *AlbumService) GetAlbums(limit int, page int) (int, []models.Album, error) {
+	const op = "service.album.GetAlbums"
+	offset := page * limit
+	albums, err := a.albumRepository.GetAlbums(limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(albums), albums, nil
+}
+
+func (a *AlbumService) GetAlbum(id int) (models.Album, error) {
+	const op = "service.album.GetAlbum"
+	album, err := a.albumRepository.GetAlbum(id)
+	if err != nil {
+		return models.Album{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return album, nil
+}
+
+func (a *AlbumService) AddAlbum(name string, releaseDate time.Time, actor models.Actor) (int, error) {
+	const op = "service.album.AddAlbum"
+	id, err := a.albumRepository.AddAlbum(name, releaseDate, actor)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("Album added", slog.Int("albumId", id), slog.String("name", name))
+	return id, nil
+}
+
+func (a *AlbumService) ChangeAlbum(id int, name string, releaseDate time.Time, actor models.Actor) error {
+	const op = "service.album.ChangeAlbum"
+	err := a.albumRepository.ChangeAlbum(id, name, releaseDate, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("Album changed", slog.Int("albumId", id))
+	return nil
+}
+
+func (a *AlbumService) DeleteAlbum(id int, actor models.Actor) error {
+	const op = "service.album.DeleteAlbum"
+	err := a.albumRepository.DeleteAlbum(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("Album deleted", slog.Int("albumId", id))
+	return nil
+}
+
+func (a *AlbumService) AddTrack(albumId int, track models.AlbumTrack, actor models.Actor) error {
+	const op = "service.album.AddTrack"
+	if track.DiscNumber == 0 {
+		track.DiscNumber = 1
+	}
+	err := a.albumRepository.AddTrack(albumId, track, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("Track added to album", slog.Int("albumId", albumId), slog.Int("songId", track.SongId),
+		slog.Int("disc", track.DiscNumber), slog.Int("track", track.TrackNumber))
+	return nil
+}
+
+func (a *AlbumService) DeleteTrack(albumId int, songId int, actor models.Actor) error {
+	const op = "service.album.DeleteTrack"
+	err := a.albumRepository.DeleteTrack(albumId, songId, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("Track deleted from album", slog.Int("albumId", albumId), slog.Int("songId", songId))
+	return nil
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"github.com/nosikmy/music-library/internal/app/policy"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix starts every key, so leaked keys are easy to find in logs and repositories
+	apiKeyPrefix = "mlk"
+	// apiKeyIdBytes is the length of the public part of a key, it is used to find the key in the db
+	apiKeyIdBytes = 8
+	// apiKeySecretBytes is the length of the secret part of a key
+	apiKeySecretBytes = 32
+)
+
+type ApiKeyRepository interface {
+	AddApiKey(key models.ApiKey, actor models.Actor) (int, error)
+	GetApiKeys(limit int, offset int) ([]models.ApiKey, error)
+	GetActiveApiKey(prefix string) (models.ApiKey, error)
+	RevokeApiKey(id int, actor models.Actor) error
+	RotateApiKey(id int, prefix string, keyHash string, actor models.Actor) error
+	TouchApiKey(id int) error
+}
+
+type ApiKeyService struct {
+	logger           *slog.Logger
+	apiKeyRepository ApiKeyRepository
+}
+
+func NewApiKeyService(logger *slog.Logger, a ApiKeyRepository) *ApiKeyService {
+	return &ApiKeyService{
+		logger:           logger,
+		apiKeyRepository: a,
+	}
+}
+
+// CreateApiKey adds a key with the scopes and returns its id and the key itself, which is not stored
+// and can not be shown again. A key without expiresAt does not expire
+func (a *ApiKeyService) CreateApiKey(name string, scopes []string, expiresAt *time.Time, createdBy int,
+	actor models.Actor) (int, string, error) {
+	const op = "service.api_key.CreateApiKey"
+	name = strings.TrimSpace(name)
+	if name == "" {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("name is empty"))
+		return 0, "", fmt.Errorf("%s: %w", op, mlErr)
+	}
+	keyScopes := make(models.Scopes, 0, len(scopes))
+	for _, scope := range scopes {
+		if !policy.IsApiKeyScope(scope) {
+			mlErr := errors.NewMusicLibraryError(errors.BadRequestError,
+				fmt.Errorf("unknown scope %q, scopes are %s", scope, strings.Join(policy.ApiKeyScopes, ", ")))
+			return 0, "", fmt.Errorf("%s: %w", op, mlErr)
+		}
+		if !slices.Contains(keyScopes, scope) {
+			keyScopes = append(keyScopes, scope)
+		}
+	}
+	if expiresAt != nil {
+		if !expiresAt.After(time.Now()) {
+			mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("expiresAt is in the past"))
+			return 0, "", fmt.Errorf("%s: %w", op, mlErr)
+		}
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	key, prefix, err := generateApiKey()
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+	apiKey := models.ApiKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashApiKey(key),
+		Scopes:    keyScopes,
+		ExpiresAt: expiresAt,
+	}
+	if createdBy != 0 {
+		apiKey.CreatedBy = &createdBy
+	}
+	id, err := a.apiKeyRepository.AddApiKey(apiKey, actor)
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("API key created", slog.Int("apiKeyId", id), slog.String("name", name),
+		slog.Any("scopes", keyScopes))
+	return id, key, nil
+}
+
+func (a *ApiKeyService) GetApiKeys(limit int, page int) (int, []models.ApiKey, error) {
+	const op = "service.api_key.GetApiKeys"
+	offset := page * limit
+	keys, err := a.apiKeyRepository.GetApiKeys(limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(keys), keys, nil
+}
+
+// RevokeApiKey stops the key from working at once
+func (a *ApiKeyService) RevokeApiKey(id int, actor models.Actor) error {
+	const op = "service.api_key.RevokeApiKey"
+	err := a.apiKeyRepository.RevokeApiKey(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("API key revoked", slog.Int("apiKeyId", id))
+	return nil
+}
+
+// RotateApiKey replaces the key with a new one and returns it, the old key stops working at once
+func (a *ApiKeyService) RotateApiKey(id int, actor models.Actor) (string, error) {
+	const op = "service.api_key.RotateApiKey"
+	key, prefix, err := generateApiKey()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	err = a.apiKeyRepository.RotateApiKey(id, prefix, hashApiKey(key), actor)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("API key rotated", slog.Int("apiKeyId", id))
+	return key, nil
+}
+
+// Authenticate returns the key if it is valid, not revoked and not expired, and records its usage
+func (a *ApiKeyService) Authenticate(key string) (models.ApiKey, error) {
+	const op = "service.api_key.Authenticate"
+	prefix, ok := apiKeyId(key)
+	if !ok {
+		mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("bad format of api key"))
+		return models.ApiKey{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	apiKey, err := a.apiKeyRepository.GetActiveApiKey(prefix)
+	if err != nil {
+		if stderrors.Is(err, errors.NotFoundError) {
+			mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("invalid api key"))
+			return models.ApiKey{}, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		return models.ApiKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashApiKey(key))) != 1 {
+		mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("invalid api key"))
+		return models.ApiKey{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	apiKey.KeyHash = ""
+
+	// A failed write of the last usage should not stop the bot
+	if err = a.apiKeyRepository.TouchApiKey(apiKey.Id); err != nil {
+		a.logger.Warn("Failed to record API key usage "+op+": "+err.Error(), slog.Int("apiKeyId", apiKey.Id))
+	}
+	return apiKey, nil
+}
+
+// generateApiKey returns a new key like mlk_<id>_<secret> and its id
+func generateApiKey() (string, string, error) {
+	id := make([]byte, apiKeyIdBytes)
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", errors.NewMusicLibraryError(errors.InternalError, err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", errors.NewMusicLibraryError(errors.InternalError, err)
+	}
+	prefix := hex.EncodeToString(id)
+	return apiKeyPrefix + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// apiKeyId returns the public id of the key, the secret part may contain underscores
+func apiKeyId(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || len(parts[1]) != 2*apiKeyIdBytes || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hashApiKey returns the SHA-256 hash of the key. The keys are random, so a slow password hash is not needed
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApiKeyId(t *testing.T) {
+	id := strings.Repeat("0f", apiKeyIdBytes)
+	tests := []struct {
+		name   string
+		key    string
+		want   string
+		wantOk bool
+	}{
+		{name: "key", key: "mlk_" + id + "_c2VjcmV0", want: id, wantOk: true},
+		{name: "underscore in secret", key: "mlk_" + id + "_se_cr_et", want: id, wantOk: true},
+		{name: "other prefix", key: "abc_" + id + "_c2VjcmV0"},
+		{name: "short id", key: "mlk_0f_c2VjcmV0"},
+		{name: "no secret", key: "mlk_" + id + "_"},
+		{name: "no secret part", key: "mlk_" + id},
+		{name: "empty", key: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := apiKeyId(tt.key)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("apiKeyId(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGeneratedApiKeyId(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		key, prefix, err := generateApiKey()
+		if err != nil {
+			t.Fatalf("generateApiKey() error = %v", err)
+		}
+		if got, ok := apiKeyId(key); !ok || got != prefix {
+			t.Fatalf("apiKeyId(%q) = %q, %v, want %q, true", key, got, ok, prefix)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+type AuditRepository interface {
+	GetAuditEvents(filter models.AuditFilter, limit int, offset int) ([]models.AuditEvent, error)
+}
+
+type AuditService struct {
+	logger          *slog.Logger
+	auditRepository AuditRepository
+}
+
+func NewAuditService(logger *slog.Logger, a AuditRepository) *AuditService {
+	return &AuditService{
+		logger:          logger,
+		auditRepository: a,
+	}
+}
+
+// GetAuditEvents returns a page of the events matching the filter, the latest first.
+// An entity id needs the type of the entity
+func (a *AuditService) GetAuditEvents(filter models.AuditFilter, limit int, page int) (int, []models.AuditEvent, error) {
+	const op = "service.audit.GetAuditEvents"
+	if filter.EntityType != "" && !slices.Contains(models.AuditEntities, filter.EntityType) {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError,
+			fmt.Errorf("unknown entity %q, entities are %s", filter.EntityType, strings.Join(models.AuditEntities, ", ")))
+		return 0, nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	if filter.EntityId != 0 && filter.EntityType == "" {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("id is set without entity"))
+		return 0, nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("from is not before to"))
+		return 0, nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	offset := page * limit
+	events, err := a.auditRepository.GetAuditEvents(filter, limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(events), events, nil
+}
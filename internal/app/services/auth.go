@@ -0,0 +1,216 @@
+package services
+
+import (
+	stderrors "errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	tokenIssuer      = "music-library"
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+)
+
+// AuthConfig of the authentication, zero TTLs are replaced with the defaults
+type AuthConfig struct {
+	// Secret signs the tokens with HMAC-SHA256
+	Secret          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type AuthService struct {
+	logger         *slog.Logger
+	userRepository UserRepository
+	cfg            AuthConfig
+	// dummyHash is compared with the password of an unknown user, so the response takes as long as for a known one
+	dummyHash []byte
+}
+
+func NewAuthService(logger *slog.Logger, u UserRepository, cfg AuthConfig) (*AuthService, error) {
+	const op = "service.auth.NewAuthService"
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("%s: secret is not set", op)
+	}
+	if cfg.AccessTokenTTL == 0 {
+		cfg.AccessTokenTTL = defaultAccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL == 0 {
+		cfg.RefreshTokenTTL = defaultRefreshTokenTTL
+	}
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &AuthService{
+		logger:         logger,
+		userRepository: u,
+		cfg:            cfg,
+		dummyHash:      dummyHash,
+	}, nil
+}
+
+// tokenClaims are the claims of the access and refresh tokens, the subject is the id of the user.
+// The role is read from the db when tokens are refreshed, so a new role applies from the next refresh
+type tokenClaims struct {
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	TokenType string `json:"tokenType"`
+	jwt.RegisteredClaims
+}
+
+// Register adds a user with the password and returns its id
+func (a *AuthService) Register(username string, password string, actor models.Actor) (int, error) {
+	const op = "service.auth.Register"
+	username = strings.TrimSpace(username)
+	if username == "" {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("username is empty"))
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return 0, fmt.Errorf("%s (failed hash password): %w", op, mlErr)
+	}
+
+	id, err := a.userRepository.AddUser(username, string(hash), actor)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("User registered", slog.Int("userId", id), slog.String("username", username))
+	return id, nil
+}
+
+// Login checks the password of the user and returns a new pair of tokens
+func (a *AuthService) Login(username string, password string) (models.Tokens, error) {
+	const op = "service.auth.Login"
+	user, err := a.userRepository.GetUserByName(strings.TrimSpace(username))
+	if err != nil && !stderrors.Is(err, errors.NotFoundError) {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	hash := []byte(user.PasswordHash)
+	if err != nil {
+		hash = a.dummyHash
+	}
+	if compareErr := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || compareErr != nil {
+		mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("invalid username or password"))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	tokens, err := a.issueTokens(user)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.logger.Info("User logged in", slog.Int("userId", user.Id))
+	return tokens, nil
+}
+
+// Refresh returns a new pair of tokens for a valid refresh token of an existing user
+func (a *AuthService) Refresh(refreshToken string) (models.Tokens, error) {
+	const op = "service.auth.Refresh"
+	claims, err := a.parseToken(refreshToken, refreshTokenType)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("bad subject of token"))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	user, err := a.userRepository.GetUser(userId)
+	if err != nil {
+		if stderrors.Is(err, errors.NotFoundError) {
+			mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("user %d does not exist", userId))
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(user)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return tokens, nil
+}
+
+// Authenticate returns the user of a valid access token. The token is trusted until it expires,
+// so the db is not queried
+func (a *AuthService) Authenticate(accessToken string) (models.User, error) {
+	const op = "service.auth.Authenticate"
+	claims, err := a.parseToken(accessToken, accessTokenType)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.UnauthorizedError, fmt.Errorf("bad subject of token"))
+		return models.User{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	return models.User{Id: userId, Username: claims.Username, Role: claims.Role}, nil
+}
+
+func (a *AuthService) issueTokens(user models.User) (models.Tokens, error) {
+	accessToken, err := a.signToken(user, accessTokenType, a.cfg.AccessTokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	refreshToken, err := a.signToken(user, refreshTokenType, a.cfg.RefreshTokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (a *AuthService) signToken(user models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		Username:  user.Username,
+		Role:      user.Role,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.Itoa(user.Id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	signed, err := token.SignedString([]byte(a.cfg.Secret))
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.InternalError, err)
+		return "", fmt.Errorf("failed sign %s token: %w", tokenType, mlErr)
+	}
+	return signed, nil
+}
+
+// parseToken checks the signature, issuer, expiry and type of the token and returns its claims
+func (a *AuthService) parseToken(token string, tokenType string) (tokenClaims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(a.cfg.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return tokenClaims{}, errors.NewMusicLibraryError(errors.UnauthorizedError, err)
+	}
+	if claims.TokenType != tokenType {
+		return tokenClaims{}, errors.NewMusicLibraryError(errors.UnauthorizedError,
+			fmt.Errorf("%s token is expected", tokenType))
+	}
+	return claims, nil
+}
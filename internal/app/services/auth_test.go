@@ -0,0 +1,116 @@
+package services
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	const secret = "test secret"
+	a := &AuthService{cfg: AuthConfig{
+		Secret:          secret,
+		AccessTokenTTL:  defaultAccessTokenTTL,
+		RefreshTokenTTL: defaultRefreshTokenTTL,
+	}}
+	user := models.User{Id: 7, Username: "matt", Role: "editor"}
+	tokens, err := a.issueTokens(user)
+	if err != nil {
+		t.Fatalf("issueTokens() error = %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, key any, change func(claims *tokenClaims)) string {
+		now := time.Now()
+		claims := tokenClaims{
+			Username:  user.Username,
+			Role:      user.Role,
+			TokenType: accessTokenType,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    tokenIssuer,
+				Subject:   strconv.Itoa(user.Id),
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		change(&claims)
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return token
+	}
+	signHS256 := func(change func(claims *tokenClaims)) string {
+		return sign(jwt.SigningMethodHS256, []byte(secret), change)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    models.User
+		wantErr bool
+	}{
+		{name: "access token", token: tokens.AccessToken, want: user},
+		{name: "refresh token", token: tokens.RefreshToken, wantErr: true},
+		{name: "no token type", token: signHS256(func(c *tokenClaims) { c.TokenType = "" }), wantErr: true},
+		{
+			name:    "expired",
+			token:   signHS256(func(c *tokenClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }),
+			wantErr: true,
+		},
+		{name: "no expiry", token: signHS256(func(c *tokenClaims) { c.ExpiresAt = nil }), wantErr: true},
+		{name: "other issuer", token: signHS256(func(c *tokenClaims) { c.Issuer = "other" }), wantErr: true},
+		{name: "bad subject", token: signHS256(func(c *tokenClaims) { c.Subject = "matt" }), wantErr: true},
+		{
+			name:    "other secret",
+			token:   sign(jwt.SigningMethodHS256, []byte("other secret"), func(*tokenClaims) {}),
+			wantErr: true,
+		},
+		{
+			name:    "other method",
+			token:   sign(jwt.SigningMethodHS512, []byte(secret), func(*tokenClaims) {}),
+			wantErr: true,
+		},
+		{
+			name:    "unsigned",
+			token:   sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, func(*tokenClaims) {}),
+			wantErr: true,
+		},
+		{name: "not a token", token: "not-a-token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if status := errors.GetHTTPError(err).Status; status != http.StatusUnauthorized {
+					t.Errorf("Authenticate() status = %d, want %d", status, http.StatusUnauthorized)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenType(t *testing.T) {
+	a := &AuthService{cfg: AuthConfig{Secret: "test secret", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour}}
+	tokens, err := a.issueTokens(models.User{Id: 7, Username: "matt", Role: "editor"})
+	if err != nil {
+		t.Fatalf("issueTokens() error = %v", err)
+	}
+	if _, err = a.parseToken(tokens.RefreshToken, refreshTokenType); err != nil {
+		t.Errorf("parseToken() of the refresh token error = %v", err)
+	}
+	if _, err = a.parseToken(tokens.AccessToken, refreshTokenType); err == nil {
+		t.Errorf("parseToken() accepted the access token as a refresh token")
+	}
+}
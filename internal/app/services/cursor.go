@@ -0,0 +1,36 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+)
+
+// encodeCursor makes an opaque token of a cursor, nil cursor gives an empty token
+func encodeCursor(cursor *models.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a token made by encodeCursor, empty token gives nil cursor
+func decodeCursor(token string) (*models.Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.NewMusicLibraryError(errors.BadRequestError, err)
+	}
+	var cursor models.Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.NewMusicLibraryError(errors.BadRequestError, err)
+	}
+	return &cursor, nil
+}
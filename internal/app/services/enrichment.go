@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// jobLease is how long a claimed job belongs to its worker, after that it can be claimed again
+	jobLease = 5 * time.Minute
+	// jobPollInterval is how long an idle worker waits before looking for jobs again
+	jobPollInterval = 2 * time.Second
+	// jobMaxAttempts is the number of attempts after which a failing job is given up
+	jobMaxAttempts  = 5
+	jobRetryWait    = 10 * time.Second
+	jobRetryMaxWait = 10 * time.Minute
+	// enrichmentActor is recorded in the audit log for the songs filled in by the workers
+	enrichmentActor = "enrichment"
+)
+
+type JobRepository interface {
+	AddSongWithJob(groups []string, song string, actor models.Actor) (int, int, error)
+	ClaimJobs(limit int, lease time.Duration) ([]models.Job, error)
+	CompleteJob(id int) error
+	FailJob(id int, jobErr string, retryAt *time.Time) error
+	GetJob(id int) (models.Job, error)
+}
+
+type EnrichmentRepository interface {
+	EnrichSong(id int, releaseDate time.Time, link string, verses []models.VerseDraft, actor models.Actor) (int, error)
+}
+
+// EnrichmentService adds draft songs and fills them with the metadata from the metadata provider in the background
+type EnrichmentService struct {
+	logger               *slog.Logger
+	jobRepository        JobRepository
+	enrichmentRepository EnrichmentRepository
+	metadataProvider     MetadataProvider
+}
+
+func NewEnrichmentService(logger *slog.Logger, j JobRepository, e EnrichmentRepository,
+	m MetadataProvider) *EnrichmentService {
+	return &EnrichmentService{
+		logger:               logger,
+		jobRepository:        j,
+		enrichmentRepository: e,
+		metadataProvider:     m,
+	}
+}
+
+// AddSongAsync adds a draft song with only its name and group and returns its id and the id of the job
+// that fills in the rest. If the song is already in the library, the job id is 0
+func (e *EnrichmentService) AddSongAsync(group string, song string, actor models.Actor) (int, int, error) {
+	const op = "service.enrichment.AddSongAsync"
+	songId, jobId, err := e.jobRepository.AddSongWithJob([]string{group}, song, actor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if jobId != 0 {
+		e.logger.Info("Song enrichment enqueued", slog.Int("songId", songId), slog.Int("jobId", jobId))
+	}
+	return songId, jobId, nil
+}
+
+func (e *EnrichmentService) GetJob(id int) (models.Job, error) {
+	const op = "service.enrichment.GetJob"
+	job, err := e.jobRepository.GetJob(id)
+	if err != nil {
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return job, nil
+}
+
+// Run runs the workers until the context is done
+func (e *EnrichmentService) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work runs claimed jobs one by one, waiting for new ones when there are none
+func (e *EnrichmentService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := e.jobRepository.ClaimJobs(1, jobLease)
+		if err != nil {
+			e.logger.Error("Error while claiming enrichment jobs: " + err.Error())
+		}
+		if len(jobs) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(jobPollInterval):
+			}
+			continue
+		}
+		e.runJob(ctx, jobs[0])
+	}
+}
+
+func (e *EnrichmentService) runJob(ctx context.Context, job models.Job) {
+	err := e.enrich(ctx, job)
+	if err == nil {
+		if err = e.jobRepository.CompleteJob(job.Id); err != nil {
+			e.logger.Error("Error while completing enrichment job: "+err.Error(), slog.Int("jobId", job.Id))
+			return
+		}
+		e.logger.Info("Song enriched", slog.Int("songId", job.SongId), slog.Int("jobId", job.Id))
+		return
+	}
+
+	var retryAt *time.Time
+	if job.Attempts < jobMaxAttempts && isTemporaryError(err) {
+		wait := min(jobRetryWait<<(job.Attempts-1), jobRetryMaxWait)
+		at := time.Now().Add(wait)
+		retryAt = &at
+	}
+	e.logger.Warn("Song enrichment failed: "+err.Error(), slog.Int("songId", job.SongId),
+		slog.Int("jobId", job.Id), slog.Int("attempts", job.Attempts), slog.Bool("retry", retryAt != nil))
+	if err = e.jobRepository.FailJob(job.Id, err.Error(), retryAt); err != nil {
+		e.logger.Error("Error while failing enrichment job: "+err.Error(), slog.Int("jobId", job.Id))
+	}
+}
+
+// enrich fills the song of the job with its metadata, the change is recorded in the audit log with the id of the job
+func (e *EnrichmentService) enrich(ctx context.Context, job models.Job) error {
+	info, err := e.metadataProvider.GetSongInfo(ctx, job.Group, job.Song)
+	if err != nil {
+		return err
+	}
+	releaseDate, verses, err := songMetadata(info)
+	if err != nil {
+		return err
+	}
+	actor := models.Actor{Name: enrichmentActor, RequestId: "job-" + strconv.Itoa(job.Id)}
+	_, err = e.enrichmentRepository.EnrichSong(job.SongId, releaseDate, info.Link, verses, actor)
+	return err
+}
+
+// isTemporaryError reports whether the operation failed with the error may succeed if repeated.
+// Errors caused by the request itself, like a song missing in the music API, are not temporary
+func isTemporaryError(err error) bool {
+	var mlErr errors.MusicLibraryError
+	if stderrors.As(err, &mlErr) {
+		return mlErr.Status >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestIsTemporaryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "bad gateway", err: errors.NewMusicLibraryError(errors.BadGatewayError, stderrors.New("timeout")), want: true},
+		{
+			name: "open breaker",
+			err:  errors.NewMusicLibraryError(errors.ServiceUnavailableError, stderrors.New("circuit breaker is open")),
+			want: true,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("op: %w", errors.NewMusicLibraryError(errors.InternalError, stderrors.New("db"))),
+			want: true,
+		},
+		{name: "missing song", err: errors.NewMusicLibraryError(errors.NotFoundError, stderrors.New("no song"))},
+		{name: "bad request", err: errors.NewMusicLibraryError(errors.BadRequestError, stderrors.New("bad group"))},
+		{name: "unknown error", err: stderrors.New("connection reset"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTemporaryError(tt.err); got != tt.want {
+				t.Errorf("isTemporaryError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// jobRecorder records how the jobs end
+type jobRecorder struct {
+	JobRepository
+	completed bool
+	failed    bool
+	retryAt   *time.Time
+}
+
+func (j *jobRecorder) CompleteJob(id int) error {
+	j.completed = true
+	return nil
+}
+
+func (j *jobRecorder) FailJob(id int, jobErr string, retryAt *time.Time) error {
+	j.failed = true
+	j.retryAt = retryAt
+	return nil
+}
+
+type fakeMetadataProvider struct {
+	info models.ApiMusicResponse
+	err  error
+}
+
+func (f fakeMetadataProvider) GetSongInfo(ctx context.Context, group string, song string) (models.ApiMusicResponse, error) {
+	return f.info, f.err
+}
+
+type fakeEnrichmentRepository struct{}
+
+func (fakeEnrichmentRepository) EnrichSong(id int, releaseDate time.Time, link string, verses []models.VerseDraft,
+	actor models.Actor) (int, error) {
+	return 2, nil
+}
+
+func TestRunJob(t *testing.T) {
+	unavailable := errors.NewMusicLibraryError(errors.BadGatewayError, stderrors.New("timeout"))
+	tests := []struct {
+		name          string
+		attempts      int
+		info          models.ApiMusicResponse
+		err           error
+		wantCompleted bool
+		wantFailed    bool
+		// wantWait is the wait before the retry, 0 if the job is given up
+		wantWait time.Duration
+	}{
+		{
+			name:          "enriched",
+			attempts:      1,
+			info:          models.ApiMusicResponse{ReleaseDate: "14.09.2009", Text: "Paranoia is in bloom"},
+			wantCompleted: true,
+		},
+		{name: "first retry", attempts: 1, err: unavailable, wantFailed: true, wantWait: jobRetryWait},
+		{name: "backoff doubles", attempts: 3, err: unavailable, wantFailed: true, wantWait: 4 * jobRetryWait},
+		{name: "last attempt", attempts: jobMaxAttempts, err: unavailable, wantFailed: true},
+		{
+			name:       "missing song",
+			attempts:   1,
+			err:        errors.NewMusicLibraryError(errors.NotFoundError, stderrors.New("no song")),
+			wantFailed: true,
+		},
+		{
+			name:       "bad release date",
+			attempts:   1,
+			info:       models.ApiMusicResponse{ReleaseDate: "2009-09-14"},
+			wantFailed: true,
+			wantWait:   jobRetryWait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &jobRecorder{}
+			e := NewEnrichmentService(slog.New(slog.NewTextHandler(io.Discard, nil)), jobs, fakeEnrichmentRepository{},
+				fakeMetadataProvider{info: tt.info, err: tt.err})
+
+			start := time.Now()
+			e.runJob(context.Background(), models.Job{Id: 3, SongId: 7, Attempts: tt.attempts})
+			if jobs.completed != tt.wantCompleted || jobs.failed != tt.wantFailed {
+				t.Fatalf("runJob() completed = %v, failed = %v, want %v, %v",
+					jobs.completed, jobs.failed, tt.wantCompleted, tt.wantFailed)
+			}
+			if tt.wantWait == 0 {
+				if jobs.retryAt != nil {
+					t.Errorf("runJob() retries at %v, want no retry", jobs.retryAt)
+				}
+				return
+			}
+			if jobs.retryAt == nil {
+				t.Fatalf("runJob() gave the job up, want a retry in %v", tt.wantWait)
+			}
+			if wait := jobs.retryAt.Sub(start); wait < tt.wantWait || wait > tt.wantWait+time.Second {
+				t.Errorf("runJob() retries in %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// exportBatchSize is the number of songs read from the db at once during an export
+const exportBatchSize = 500
+
+// exportCSVHeader is the header of a CSV export, the columns are the ones readCSVRows expects
+var exportCSVHeader = []string{"group", "song", "releaseDate", "link", "text", "groups", "draft"}
+
+// Export writes every song matching the filter with its groups and verses in the format, in batches,
+// so the whole library is never held in memory. The output can be imported back
+func (l *LibraryService) Export(format string, filter models.LibraryFilter, w io.Writer) error {
+	const op = "service.library.Export"
+	writer, err := newExportWriter(format, w)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	exported := 0
+	for afterId := 0; ; {
+		rows, err := l.libraryRepository.GetExportBatch(filter, afterId, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		songs := collectSongs(rows)
+		if len(songs) == 0 {
+			break
+		}
+
+		songIds := make([]int, 0, len(songs))
+		for _, song := range songs {
+			songIds = append(songIds, song.Id)
+		}
+		verses, err := l.libraryRepository.GetVersesOfSongs(songIds)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, song := range songs {
+			if err = writer.write(exportRow(song, verses[song.Id])); err != nil {
+				return fmt.Errorf("%s (failed write song %d): %w", op, song.Id, err)
+			}
+		}
+		if err = writer.flush(); err != nil {
+			return fmt.Errorf("%s (failed flush): %w", op, err)
+		}
+		exported += len(songs)
+		afterId = songs[len(songs)-1].Id
+	}
+
+	if err = writer.close(); err != nil {
+		return fmt.Errorf("%s (failed close): %w", op, err)
+	}
+	l.logger.Info("Library exported", slog.String("format", format), slog.Int("songs", exported))
+	return nil
+}
+
+// exportRow makes the import row of the song, its text is the verses separated by blank lines.
+// A song without verses is marked as a draft, so that the import does not look up its metadata
+func exportRow(song models.Song, verses []models.Verse) models.ImportRow {
+	row := models.ImportRow{
+		Song:   song.Name,
+		Link:   song.Link,
+		Groups: make([]string, 0, len(song.Groups)),
+		Verses: verses,
+		Draft:  len(verses) == 0,
+	}
+	for _, group := range song.Groups {
+		row.Groups = append(row.Groups, group.Name)
+	}
+	if len(row.Groups) > 0 {
+		row.Group = row.Groups[0]
+	}
+	// drafts have no release date
+	if song.ReleaseDate.Year() > 1 {
+		row.ReleaseDate = song.ReleaseDate.Format(models.DateLayout)
+	}
+	texts := make([]string, 0, len(verses))
+	for _, verse := range verses {
+		texts = append(texts, verse.Text)
+	}
+	row.Text = strings.Join(texts, "\n\n")
+	return row
+}
+
+// exportWriter writes the rows of an export in a format
+type exportWriter struct {
+	w      io.Writer
+	format string
+	rows   int
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func newExportWriter(format string, w io.Writer) (*exportWriter, error) {
+	writer := &exportWriter{w: w, format: format}
+	switch format {
+	case ImportFormatCSV:
+		writer.csv = csv.NewWriter(w)
+	case ImportFormatNDJSON, ImportFormatJSON:
+		writer.json = json.NewEncoder(w)
+		writer.json.SetEscapeHTML(false)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	return writer, nil
+}
+
+func (e *exportWriter) write(row models.ImportRow) error {
+	defer func() { e.rows++ }()
+	switch e.format {
+	case ImportFormatCSV:
+		if e.rows == 0 {
+			if err := e.csv.Write(exportCSVHeader); err != nil {
+				return err
+			}
+		}
+		// verses do not fit in a cell, the text keeps the lyrics
+		draft := ""
+		if row.Draft {
+			draft = "true"
+		}
+		return e.csv.Write([]string{row.Group, row.Song, row.ReleaseDate, row.Link, row.Text,
+			strings.Join(row.Groups, "\n"), draft})
+	case ImportFormatJSON:
+		separator := ","
+		if e.rows == 0 {
+			separator = "["
+		}
+		if _, err := io.WriteString(e.w, separator); err != nil {
+			return err
+		}
+	}
+	return e.json.Encode(row)
+}
+
+// flush sends the written rows to the client if the writer buffers them
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := e.w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// close finishes the output, an export without songs is still a valid document
+func (e *exportWriter) close() error {
+	switch e.format {
+	case ImportFormatCSV:
+		if e.rows == 0 {
+			if err := e.csv.Write(exportCSVHeader); err != nil {
+				return err
+			}
+		}
+	case ImportFormatJSON:
+		end := "]\n"
+		if e.rows == 0 {
+			end = "[]\n"
+		}
+		if _, err := io.WriteString(e.w, end); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
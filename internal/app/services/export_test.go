@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// copiedSong is a song added by the import of an export
+type copiedSong struct {
+	groups      []string
+	song        string
+	releaseDate *time.Time
+	link        string
+	verses      []models.VerseDraft
+}
+
+// copyRepository is an empty library the export is imported into
+type copyRepository struct {
+	JobRepository
+	songs []copiedSong
+	jobs  int
+}
+
+func (c *copyRepository) AddSong(groups []string, song string, releaseDate *time.Time, verses []models.VerseDraft,
+	link string, actor models.Actor) (int, bool, error) {
+	c.songs = append(c.songs, copiedSong{groups: groups, song: song, releaseDate: releaseDate, link: link, verses: verses})
+	return len(c.songs), true, nil
+}
+
+func (c *copyRepository) AddSongWithJob(groups []string, song string, actor models.Actor) (int, int, error) {
+	c.jobs++
+	return 0, c.jobs, nil
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	releaseDate := time.Date(2009, 9, 14, 0, 0, 0, 0, time.UTC)
+	library := []struct {
+		song   models.Song
+		verses []models.Verse
+	}{
+		{
+			song: models.Song{
+				Id:          1,
+				Name:        "Uprising",
+				ReleaseDate: releaseDate,
+				Link:        "https://example.com/uprising",
+				Groups:      []models.Group{{Id: 1, Name: "Muse"}, {Id: 2, Name: "Guest"}},
+			},
+			verses: []models.Verse{
+				{
+					Id: 11, Kind: models.VerseKindVerse, Text: "Paranoia is in bloom",
+					StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000},
+				},
+				{Id: 12, Kind: models.VerseKindChorus, Label: "Chorus", Text: "They will not force us"},
+				{Id: 13, Kind: models.VerseKindChorus, Label: "Chorus", RepeatOf: intPtr(12), Text: "They will not force us"},
+			},
+		},
+		{
+			// a draft that has not got its metadata yet
+			song: models.Song{Id: 2, Name: "Resistance", Groups: []models.Group{{Id: 1, Name: "Muse"}}},
+		},
+		{
+			song: models.Song{Id: 3, Name: "Exogenesis", ReleaseDate: releaseDate, Groups: []models.Group{}},
+			verses: []models.Verse{
+				{Id: 31, Kind: models.VerseKindVerse, Text: "Who are we?"},
+			},
+		},
+	}
+
+	draft := copiedSong{groups: []string{"Muse"}, song: "Resistance", verses: []models.VerseDraft{}}
+	groupless := copiedSong{
+		song:        "Exogenesis",
+		releaseDate: &releaseDate,
+		verses:      []models.VerseDraft{{Text: "Who are we?", Kind: models.VerseKindVerse}},
+	}
+	uprising := copiedSong{
+		groups:      []string{"Muse", "Guest"},
+		song:        "Uprising",
+		releaseDate: &releaseDate,
+		link:        "https://example.com/uprising",
+	}
+	uprisingJSON := uprising
+	uprisingJSON.verses = []models.VerseDraft{
+		{Text: "Paranoia is in bloom", Kind: models.VerseKindVerse, StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000}},
+		{Text: "They will not force us", Kind: models.VerseKindChorus, Label: "Chorus"},
+		{Kind: models.VerseKindChorus, Repeats: intPtr(1)},
+	}
+	// CSV keeps only the text of the verses, the repeats are found again
+	uprisingCSV := uprising
+	uprisingCSV.verses = []models.VerseDraft{
+		{Text: "Paranoia is in bloom", Kind: models.VerseKindVerse},
+		{Text: "They will not force us", Kind: models.VerseKindChorus},
+		{Kind: models.VerseKindChorus, Repeats: intPtr(1)},
+	}
+
+	tests := []struct {
+		format string
+		want   []copiedSong
+	}{
+		{format: ImportFormatCSV, want: []copiedSong{uprisingCSV, draft, groupless}},
+		{format: ImportFormatNDJSON, want: []copiedSong{uprisingJSON, draft, groupless}},
+		{format: ImportFormatJSON, want: []copiedSong{uprisingJSON, draft, groupless}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var exported bytes.Buffer
+			writer, err := newExportWriter(tt.format, &exported)
+			if err != nil {
+				t.Fatalf("newExportWriter() error = %v", err)
+			}
+			for _, entry := range library {
+				if err = writer.write(exportRow(entry.song, entry.verses)); err != nil {
+					t.Fatalf("write() error = %v", err)
+				}
+			}
+			if err = writer.close(); err != nil {
+				t.Fatalf("close() error = %v", err)
+			}
+
+			repository := &copyRepository{}
+			i := NewImportService(slog.New(slog.NewTextHandler(io.Discard, nil)), repository, repository)
+			report, err := i.Import(tt.format, bytes.NewReader(exported.Bytes()), models.Actor{Name: "anna"})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if report.Created != len(library) || report.Failed != 0 {
+				t.Errorf("Import() report = %+v, want %d songs created", report, len(library))
+			}
+			if repository.jobs != 0 {
+				t.Errorf("Import() queued %d enrichment jobs, want none", repository.jobs)
+			}
+			if !reflect.DeepEqual(repository.songs, tt.want) {
+				t.Errorf("imported songs = %+v\nwant %+v\nexport:\n%s", repository.songs, tt.want, exported.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+)
+
+type GroupRepository interface {
+	GetGroups(limit int, offset int, searchText string) ([]models.Group, error)
+	GetGroup(id int) (models.Group, error)
+	GetGroupSongs(id int) ([]models.SongDBFormat, error)
+	RenameGroup(id int, newName string, actor models.Actor) error
+	MergeGroups(id int, mergedIds []int, actor models.Actor) error
+	DeleteGroup(id int, actor models.Actor) error
+}
+
+type GroupService struct {
+	logger          *slog.Logger
+	groupRepository GroupRepository
+}
+
+func NewGroupService(logger *slog.Logger, g GroupRepository) *GroupService {
+	return &GroupService{
+		logger:          logger,
+		groupRepository: g,
+	}
+}
+
+func (g *GroupService) GetGroups(limit int, page int, searchText string) (int, []models.Group, error) {
+	const op = "service.group.GetGroups"
+	offset := page * limit
+	groups, err := g.groupRepository.GetGroups(limit, offset, searchText)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(groups), groups, nil
+}
+
+func (g *GroupService) GetGroup(id int) (models.Group, error) {
+	const op = "service.group.GetGroup"
+	group, err := g.groupRepository.GetGroup(id)
+	if err != nil {
+		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return group, nil
+}
+
+func (g *GroupService) GetGroupSongs(id int) (int, []models.Song, error) {
+	const op = "service.group.GetGroupSongs"
+	if _, err := g.groupRepository.GetGroup(id); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	songsDB, err := g.groupRepository.GetGroupSongs(id)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	songs := collectSongs(songsDB)
+	return len(songs), songs, nil
+}
+
+func (g *GroupService) RenameGroup(id int, newName string, actor models.Actor) error {
+	const op = "service.group.RenameGroup"
+	err := g.groupRepository.RenameGroup(id, newName, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	g.logger.Info("Group renamed", slog.Int("groupId", id), slog.String("newName", newName))
+	return nil
+}
+
+func (g *GroupService) MergeGroups(id int, mergedIds []int, actor models.Actor) error {
+	const op = "service.group.MergeGroups"
+	seen := map[int]bool{id: true}
+	var toMerge []int
+	for _, mergedId := range mergedIds {
+		if !seen[mergedId] {
+			seen[mergedId] = true
+			toMerge = append(toMerge, mergedId)
+		}
+	}
+	if len(toMerge) == 0 {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError,
+			fmt.Errorf("no groups other than %d to merge", id))
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	err := g.groupRepository.MergeGroups(id, toMerge, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	g.logger.Info("Groups merged", slog.Int("groupId", id), slog.Any("mergedIds", toMerge))
+	return nil
+}
+
+func (g *GroupService) DeleteGroup(id int, actor models.Actor) error {
+	const op = "service.group.DeleteGroup"
+	err := g.groupRepository.DeleteGroup(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	g.logger.Info("Group deleted", slog.Int("groupId", id))
+	return nil
+}
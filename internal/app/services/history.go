@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"strings"
+)
+
+type RevisionRepository interface {
+	GetRevisions(songId int, limit int, offset int) ([]models.Revision, error)
+	GetRevision(songId int, revision int) (models.Revision, error)
+}
+
+// GetHistory returns a page of revisions of the song, the latest first
+func (s *SongService) GetHistory(id int, limit int, page int) (int, []models.Revision, error) {
+	const op = "service.history.GetHistory"
+	offset := limit * page
+	revisions, err := s.revisionRepository.GetRevisions(id, limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(revisions), revisions, nil
+}
+
+// GetRevisionDiff returns a line diff of the lyrics before and after the revision
+func (s *SongService) GetRevisionDiff(id int, revision int) ([]models.DiffLine, error) {
+	const op = "service.history.GetRevisionDiff"
+	rev, err := s.revisionRepository.GetRevision(id, revision)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return diffLines(lyricsLines(rev.Before.Verses), lyricsLines(rev.After.Verses)), nil
+}
+
+// RestoreRevision brings the name, groups and verses of the song back to their state right after the revision
+// within a single transaction. The restore is recorded as a new revision.
+// If version is not 0, the song must have this version.
+// The number of the new revision and the new version of the song are returned
+func (s *SongService) RestoreRevision(id int, revision int, actor models.Actor, version int) (int, int, error) {
+	const op = "service.history.RestoreRevision"
+	rev, err := s.revisionRepository.GetRevision(id, revision)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	current, currentVersion, err := s.songDocument(id)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if version != 0 && version != currentVersion {
+		mlErr := errors.NewMusicLibraryError(errors.PreconditionFailedError,
+			fmt.Errorf("song %d has version %d, not %d", id, currentVersion, version))
+		return 0, 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	target := rev.After
+
+	var operations []models.SongOperation
+	if current.Name != target.Name {
+		operations = append(operations, models.SongOperation{Op: models.SongOpRename, Name: target.Name})
+	}
+	for _, group := range target.Groups {
+		if !containsGroup(current.Groups, group) {
+			operations = append(operations, models.SongOperation{Op: models.SongOpAddGroup, Name: group.Name})
+		}
+	}
+	for _, group := range current.Groups {
+		if !containsGroup(target.Groups, group) {
+			operations = append(operations, models.SongOperation{Op: models.SongOpRemoveGroup, GroupId: group.Id})
+		}
+	}
+
+	// the operations are computed against currentVersion and must not be applied to another one
+	newVersion, newRevision, err := s.songChangerRepository.RevertSong(id, currentVersion, operations,
+		versesToDrafts(target.Verses), actor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	s.logger.Info("Song restored", slog.Int("songId", id), slog.Int("revision", revision),
+		slog.Int("newRevision", newRevision), slog.Int("version", newVersion))
+	return newRevision, newVersion, nil
+}
+
+// containsGroup reports whether the group is in the list. A group is matched by id or by name,
+// so a group deleted and added again under the same name is still the same group
+func containsGroup(groups []models.Group, group models.Group) bool {
+	for _, g := range groups {
+		if g.Id == group.Id || g.Name == group.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// versesToDrafts converts verses of a snapshot into drafts for inserting them again.
+// A repeat of a verse that comes later in the song keeps a copy of the text instead
+func versesToDrafts(verses []models.Verse) []models.VerseDraft {
+	indexes := make(map[int]int, len(verses))
+	drafts := make([]models.VerseDraft, 0, len(verses))
+	for i, verse := range verses {
+		indexes[verse.Id] = i
+		draft := models.VerseDraft{
+			Text:         verse.Text,
+			Kind:         verse.Kind,
+			Label:        verse.Label,
+			StartMs:      verse.StartMs,
+			EndMs:        verse.EndMs,
+			LineStartsMs: verse.LineStartsMs,
+		}
+		if verse.RepeatOf != nil {
+			if origin, ok := indexes[*verse.RepeatOf]; ok {
+				draft.Text = ""
+				if draft.Label == verses[origin].Label {
+					draft.Label = ""
+				}
+				draft.Repeats = &origin
+			}
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts
+}
+
+// lyricsLines returns the lines of the lyrics with an empty line between verses
+func lyricsLines(verses []models.Verse) []string {
+	var lines []string
+	for i, verse := range verses {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(verse.Text, "\n")...)
+	}
+	return lines
+}
+
+// diffLines returns a line diff turning a into b, built on the longest common subsequence of lines
+func diffLines(a []string, b []string) []models.DiffLine {
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	diff := []models.DiffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, models.DiffLine{Op: models.DiffOpEqual, Text: a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			diff = append(diff, models.DiffLine{Op: models.DiffOpDelete, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, models.DiffLine{Op: models.DiffOpInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, models.DiffLine{Op: models.DiffOpDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, models.DiffLine{Op: models.DiffOpInsert, Text: b[j]})
+	}
+	return diff
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"reflect"
+	"testing"
+)
+
+func TestLyricsLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		verses []models.Verse
+		want   []string
+	}{
+		{name: "no verses"},
+		{name: "one verse", verses: []models.Verse{{Text: "a\nb"}}, want: []string{"a", "b"}},
+		{name: "verses", verses: []models.Verse{{Text: "a\nb"}, {Text: "c"}}, want: []string{"a", "b", "", "c"}},
+		{name: "repeat without text", verses: []models.Verse{{Text: "a"}, {RepeatOf: intPtr(1)}}, want: []string{"a", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lyricsLines(tt.verses); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lyricsLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	equal := func(text string) models.DiffLine { return models.DiffLine{Op: models.DiffOpEqual, Text: text} }
+	del := func(text string) models.DiffLine { return models.DiffLine{Op: models.DiffOpDelete, Text: text} }
+	ins := func(text string) models.DiffLine { return models.DiffLine{Op: models.DiffOpInsert, Text: text} }
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []models.DiffLine
+	}{
+		{name: "empty", want: []models.DiffLine{}},
+		{name: "equal", a: []string{"a", "b"}, b: []string{"a", "b"}, want: []models.DiffLine{equal("a"), equal("b")}},
+		{name: "all inserted", b: []string{"a", "b"}, want: []models.DiffLine{ins("a"), ins("b")}},
+		{name: "all deleted", a: []string{"a", "b"}, want: []models.DiffLine{del("a"), del("b")}},
+		{name: "changed line", a: []string{"a"}, b: []string{"b"}, want: []models.DiffLine{del("a"), ins("b")}},
+		{
+			name: "changed line in the middle",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "B", "c"},
+			want: []models.DiffLine{equal("a"), del("b"), ins("B"), equal("c")},
+		},
+		{
+			name: "inserted line",
+			a:    []string{"a", "c"},
+			b:    []string{"a", "b", "c"},
+			want: []models.DiffLine{equal("a"), ins("b"), equal("c")},
+		},
+		{
+			name: "deleted line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "c"},
+			want: []models.DiffLine{equal("a"), del("b"), equal("c")},
+		},
+		{
+			name: "moved line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"b", "c", "a"},
+			want: []models.DiffLine{del("a"), equal("b"), equal("c"), ins("a")},
+		},
+		{
+			name: "appended verse",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b", "", "c"},
+			want: []models.DiffLine{equal("a"), equal("b"), ins(""), ins("c")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%q, %q) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
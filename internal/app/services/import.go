@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+	ImportFormatJSON   = "json"
+)
+
+// maxImportLine is the longest line of an NDJSON import, a line holds all lyrics of a song
+const maxImportLine = 1 << 20
+
+type ImportRepository interface {
+	AddSong(groups []string, song string, releaseDate *time.Time, verses []models.VerseDraft, link string,
+		actor models.Actor) (int, bool, error)
+}
+
+type ImportService struct {
+	logger           *slog.Logger
+	importRepository ImportRepository
+	jobRepository    JobRepository
+}
+
+func NewImportService(logger *slog.Logger, i ImportRepository, j JobRepository) *ImportService {
+	return &ImportService{
+		logger:           logger,
+		importRepository: i,
+		jobRepository:    j,
+	}
+}
+
+// Import adds the songs of the rows read in the format and reports the outcome of every row.
+// Rows with lyrics and drafts are added as they are, the others are enriched from the music API in the background.
+// A song already in the library is left unchanged. A bad row does not stop the import
+func (i *ImportService) Import(format string, r io.Reader, actor models.Actor) (models.ImportReport, error) {
+	const op = "service.import.Import"
+	report := models.ImportReport{Rows: []models.ImportResult{}}
+	err := readImportRows(format, r, func(row int, importRow models.ImportRow, rowErr error) {
+		result := models.ImportResult{Row: row, Group: importRow.Group, Song: importRow.Song}
+		if rowErr == nil {
+			result.Id, result.JobId, result.Status, rowErr = i.importRow(importRow, actor)
+		}
+		if rowErr != nil {
+			result.Status = models.ImportStatusFailed
+			result.Error = rowErr.Error()
+		}
+
+		switch result.Status {
+		case models.ImportStatusCreated:
+			report.Created++
+		case models.ImportStatusExisting:
+			report.Existing++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	})
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return models.ImportReport{}, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	i.logger.Info("Songs imported", slog.Int("created", report.Created), slog.Int("existing", report.Existing),
+		slog.Int("failed", report.Failed))
+	return report, nil
+}
+
+// importRow adds the song of the row and returns its id, the id of its enrichment job if there is one and the status
+func (i *ImportService) importRow(row models.ImportRow, actor models.Actor) (int, int, string, error) {
+	song := strings.TrimSpace(row.Song)
+	// the group of the row goes first, the song is looked up by it
+	var groups []string
+	for _, name := range append([]string{row.Group}, row.Groups...) {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(groups, name) {
+			groups = append(groups, name)
+		}
+	}
+	if song == "" {
+		return 0, 0, "", fmt.Errorf("song is required")
+	}
+
+	if !row.Draft && strings.TrimSpace(row.Text) == "" && len(row.Verses) == 0 {
+		if len(groups) == 0 {
+			return 0, 0, "", fmt.Errorf("group is required to look up the song")
+		}
+		songId, jobId, err := i.jobRepository.AddSongWithJob(groups, song, actor)
+		if err != nil {
+			return 0, 0, "", importError(err)
+		}
+		if jobId == 0 {
+			return songId, 0, models.ImportStatusExisting, nil
+		}
+		return songId, jobId, models.ImportStatusCreated, nil
+	}
+
+	var releaseDate *time.Time
+	if row.ReleaseDate != "" {
+		date, err := time.Parse(models.DateLayout, strings.TrimSpace(row.ReleaseDate))
+		if err != nil {
+			return 0, 0, "", fmt.Errorf("release date must be in the format %s", models.DateLayout)
+		}
+		releaseDate = &date
+	}
+	verses := versesToDrafts(row.Verses)
+	if len(verses) == 0 && strings.TrimSpace(row.Text) != "" {
+		verses = splitVerses(row.Text)
+	}
+	songId, created, err := i.importRepository.AddSong(groups, song, releaseDate, verses, strings.TrimSpace(row.Link),
+		actor)
+	if err != nil {
+		return 0, 0, "", importError(err)
+	}
+	if !created {
+		return songId, 0, models.ImportStatusExisting, nil
+	}
+	return songId, 0, models.ImportStatusCreated, nil
+}
+
+// importError hides the details of an internal error behind its music library error
+func importError(err error) error {
+	var mlErr errors.MusicLibraryError
+	if stderrors.As(err, &mlErr) {
+		return mlErr
+	}
+	return err
+}
+
+// readImportRows reads the rows in the format and calls fn for every row, numbered from 1.
+// A row that can not be read is passed with an error, an error is returned only if the whole input is bad
+func readImportRows(format string, r io.Reader, fn func(row int, importRow models.ImportRow, rowErr error)) error {
+	switch format {
+	case ImportFormatCSV:
+		return readCSVRows(r, fn)
+	case ImportFormatNDJSON:
+		return readNDJSONRows(r, fn)
+	case ImportFormatJSON:
+		return readJSONRows(r, fn)
+	default:
+		return fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+// readCSVRows reads CSV with a header naming the columns: group, song, releaseDate, link, text, groups and draft.
+// Only group and song are required, unknown columns are ignored. Groups are separated by new lines
+func readCSVRows(r io.Reader, fn func(row int, importRow models.ImportRow, rowErr error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if stderrors.Is(err, io.EOF) {
+			return fmt.Errorf("no header")
+		}
+		return fmt.Errorf("bad header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"group", "song"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("no %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if stderrors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !stderrors.As(err, &parseErr) {
+				return err
+			}
+			fn(row, models.ImportRow{}, err)
+			continue
+		}
+		draft := false
+		if cell := strings.TrimSpace(field(record, "draft")); cell != "" {
+			if draft, err = strconv.ParseBool(cell); err != nil {
+				fn(row, models.ImportRow{}, fmt.Errorf("draft must be true or false"))
+				continue
+			}
+		}
+		fn(row, models.ImportRow{
+			Group:       field(record, "group"),
+			Song:        field(record, "song"),
+			ReleaseDate: field(record, "releaseDate"),
+			Link:        field(record, "link"),
+			Text:        field(record, "text"),
+			Groups:      splitGroups(field(record, "groups")),
+			Draft:       draft,
+		}, nil)
+	}
+}
+
+// readNDJSONRows reads a JSON object per line, blank lines are skipped
+func readNDJSONRows(r io.Reader, fn func(row int, importRow models.ImportRow, rowErr error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		row++
+		var importRow models.ImportRow
+		if err := json.Unmarshal([]byte(line), &importRow); err != nil {
+			fn(row, models.ImportRow{}, fmt.Errorf("bad JSON: %w", err))
+			continue
+		}
+		fn(row, importRow, nil)
+	}
+	return scanner.Err()
+}
+
+// readJSONRows reads a JSON array of objects without loading the whole array
+func readJSONRows(r io.Reader, fn func(row int, importRow models.ImportRow, rowErr error)) error {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return fmt.Errorf("a JSON array is expected")
+	}
+	for row := 1; decoder.More(); row++ {
+		var importRow models.ImportRow
+		if err := decoder.Decode(&importRow); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if !stderrors.As(err, &typeErr) {
+				return fmt.Errorf("bad JSON in row %d: %w", row, err)
+			}
+			// the decoder is past the bad object and can go on
+			fn(row, models.ImportRow{}, fmt.Errorf("bad JSON: %w", err))
+			continue
+		}
+		fn(row, importRow, nil)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("bad end of JSON array: %w", err)
+	}
+	return nil
+}
+
+// splitGroups splits the groups of a CSV cell, one group per line
+func splitGroups(cell string) []string {
+	if strings.TrimSpace(cell) == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(cell, "\r\n", "\n"), "\n")
+}
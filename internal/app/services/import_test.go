@@ -0,0 +1,209 @@
+package services
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type readRow struct {
+	row       int
+	importRow models.ImportRow
+	failed    bool
+}
+
+func TestReadImportRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []readRow
+		wantErr bool
+	}{
+		{
+			name:   "csv",
+			format: ImportFormatCSV,
+			input: "\ufeffGroup,song,releaseDate,extra,groups\n" +
+				"Muse,Uprising,14.09.2009,x,\"Muse\nGuest\"\n" +
+				"Muse,Resistance\n",
+			want: []readRow{
+				{row: 1, importRow: models.ImportRow{
+					Group: "Muse", Song: "Uprising", ReleaseDate: "14.09.2009", Groups: []string{"Muse", "Guest"},
+				}},
+				{row: 2, importRow: models.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{
+			name:   "csv bad row",
+			format: ImportFormatCSV,
+			input:  "group,song\nMuse,Up\"rising\nMuse,Resistance\n",
+			want: []readRow{
+				{row: 1, failed: true},
+				{row: 2, importRow: models.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{name: "csv without song column", format: ImportFormatCSV, input: "group,name\nMuse,Uprising\n", wantErr: true},
+		{name: "csv without header", format: ImportFormatCSV, input: "", wantErr: true},
+		{
+			name:   "ndjson",
+			format: ImportFormatNDJSON,
+			input: `{"group": "Muse", "song": "Uprising", "text": "Paranoia is in bloom"}` + "\n\n" +
+				`{"group": "Muse", "song": ` + "\n" +
+				`{"group": "Muse", "song": "Resistance", "groups": ["Guest"]}` + "\n",
+			want: []readRow{
+				{row: 1, importRow: models.ImportRow{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"}},
+				{row: 2, failed: true},
+				{row: 3, importRow: models.ImportRow{Group: "Muse", Song: "Resistance", Groups: []string{"Guest"}}},
+			},
+		},
+		{
+			name:   "json",
+			format: ImportFormatJSON,
+			input:  `[{"group": "Muse", "song": "Uprising"}, {"group": 1}, {"group": "Muse", "song": "Resistance"}]`,
+			want: []readRow{
+				{row: 1, importRow: models.ImportRow{Group: "Muse", Song: "Uprising"}},
+				{row: 2, failed: true},
+				{row: 3, importRow: models.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{name: "json object", format: ImportFormatJSON, input: `{"group": "Muse"}`, wantErr: true},
+		{name: "json broken", format: ImportFormatJSON, input: `[{"group": "Muse"`, wantErr: true},
+		{name: "unknown format", format: "xml", input: "<songs/>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []readRow
+			err := readImportRows(tt.format, strings.NewReader(tt.input),
+				func(row int, importRow models.ImportRow, rowErr error) {
+					got = append(got, readRow{row: row, importRow: importRow, failed: rowErr != nil})
+				})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readImportRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readImportRows() rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// addedSong is a song added through the fake repositories
+type addedSong struct {
+	groups      []string
+	song        string
+	releaseDate *time.Time
+	draft       bool
+}
+
+type fakeImportRepository struct {
+	added    []addedSong
+	existing bool
+}
+
+func (f *fakeImportRepository) AddSong(groups []string, song string, releaseDate *time.Time,
+	verses []models.VerseDraft, link string, actor models.Actor) (int, bool, error) {
+	if f.existing {
+		return 7, false, nil
+	}
+	f.added = append(f.added, addedSong{groups: groups, song: song, releaseDate: releaseDate})
+	return 7, true, nil
+}
+
+// fakeJobRepository implements only AddSongWithJob, the other methods are not called by the import
+type fakeJobRepository struct {
+	JobRepository
+	importRepository *fakeImportRepository
+}
+
+func (f *fakeJobRepository) AddSongWithJob(groups []string, song string, actor models.Actor) (int, int, error) {
+	if f.importRepository.existing {
+		return 7, 0, nil
+	}
+	f.importRepository.added = append(f.importRepository.added, addedSong{groups: groups, song: song, draft: true})
+	return 7, 3, nil
+}
+
+func TestImportRow(t *testing.T) {
+	releaseDate := time.Date(2009, 9, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		row        models.ImportRow
+		existing   bool
+		wantJobId  int
+		wantStatus string
+		want       []addedSong
+		wantErr    bool
+	}{
+		{
+			name: "song with groups",
+			row: models.ImportRow{
+				Group: " Muse ", Song: "Uprising", ReleaseDate: "14.09.2009", Text: "Paranoia is in bloom",
+				Groups: []string{"Muse", "Guest", " ", "Guest", "Other"},
+			},
+			wantStatus: models.ImportStatusCreated,
+			want: []addedSong{
+				{groups: []string{"Muse", "Guest", "Other"}, song: "Uprising", releaseDate: &releaseDate},
+			},
+		},
+		{
+			name:       "first of the groups",
+			row:        models.ImportRow{Song: "Uprising", Text: "Paranoia is in bloom", Groups: []string{"Muse", "Guest"}},
+			wantStatus: models.ImportStatusCreated,
+			want:       []addedSong{{groups: []string{"Muse", "Guest"}, song: "Uprising"}},
+		},
+		{
+			name:       "draft with groups",
+			row:        models.ImportRow{Group: "Muse", Song: "Uprising", Groups: []string{"Guest"}},
+			wantJobId:  3,
+			wantStatus: models.ImportStatusCreated,
+			want:       []addedSong{{groups: []string{"Muse", "Guest"}, song: "Uprising", draft: true}},
+		},
+		{
+			name:       "existing song",
+			row:        models.ImportRow{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"},
+			existing:   true,
+			wantStatus: models.ImportStatusExisting,
+		},
+		{
+			name:       "existing draft",
+			row:        models.ImportRow{Group: "Muse", Song: "Uprising"},
+			existing:   true,
+			wantStatus: models.ImportStatusExisting,
+		},
+		{name: "no song", row: models.ImportRow{Group: "Muse", Text: "Paranoia is in bloom"}, wantErr: true},
+		{name: "no group", row: models.ImportRow{Song: "Uprising", Groups: []string{" "}}, wantErr: true},
+		{
+			name:    "bad release date",
+			row:     models.ImportRow{Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-14", Text: "a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importRepository := &fakeImportRepository{existing: tt.existing}
+			i := NewImportService(slog.New(slog.NewTextHandler(io.Discard, nil)), importRepository,
+				&fakeJobRepository{importRepository: importRepository})
+
+			id, jobId, status, err := i.importRow(tt.row, models.Actor{Name: "anna"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("importRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(importRepository.added) != 0 {
+					t.Errorf("importRow() added %+v for a bad row", importRepository.added)
+				}
+				return
+			}
+			if id != 7 || jobId != tt.wantJobId || status != tt.wantStatus {
+				t.Errorf("importRow() = %d, %d, %s, want 7, %d, %s", id, jobId, status, tt.wantJobId, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(importRepository.added, tt.want) {
+				t.Errorf("importRow() added %+v, want %+v", importRepository.added, tt.want)
+			}
+		})
+	}
+}
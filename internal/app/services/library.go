@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"github.com/nosikmy/music-library/internal/app/models"
 	"log/slog"
-	"time"
 )
 
 type LibraryRepository interface {
-	GetLibrary(limit int, offset int,
-		searchText string, dateFrom, dateTo time.Time) ([]models.SongDBFormat, error)
+	GetLibrary(limit int, offset int, filter models.LibraryFilter,
+		cursor *models.Cursor) ([]models.SongDBFormat, int, *models.Cursor, error)
+	Search(limit int, offset int, searchText string) ([]models.SearchHit, error)
+	GetExportBatch(filter models.LibraryFilter, afterId int, limit int) ([]models.SongDBFormat, error)
+	GetVersesOfSongs(songIds []int) (map[int][]models.Verse, error)
 }
 
 type LibraryService struct {
@@ -24,38 +26,67 @@ func NewLibraryService(logger *slog.Logger, l LibraryRepository) *LibraryService
 	}
 }
 
-func (l *LibraryService) GetLibrary(limit int, page int, searchText string, dateFrom, dateTo time.Time) (int, []models.Song, error) {
+// GetLibrary returns a page of songs matching the filter, the total number of matching songs
+// and the cursor of the next page. The page starts after the cursor if it is set, otherwise page is used
+func (l *LibraryService) GetLibrary(limit int, page int, cursor string,
+	filter models.LibraryFilter) (int, []models.Song, string, error) {
 	const op = "service.library.GetLibrary"
 	offset := page * limit
-	libraryDB, err := l.libraryRepository.GetLibrary(limit, offset, searchText, dateFrom, dateTo)
+	after, err := decodeCursor(cursor)
 	if err != nil {
-		return 0, nil, fmt.Errorf("%s: %w", op, err)
+		return 0, nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	libraryDB, total, next, err := l.libraryRepository.GetLibrary(limit, offset, filter, after)
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	l.logger.Debug("library data from db", libraryDB)
+	l.logger.Debug("library data from db", slog.Any("rows", libraryDB))
+
+	library := collectSongs(libraryDB)
+
+	return total, library, encodeCursor(next), nil
+}
+
+func (l *LibraryService) Search(limit int, page int, searchText string) (int, []models.SearchHit, error) {
+	const op = "service.library.Search"
+	offset := page * limit
+	hits, err := l.libraryRepository.Search(limit, offset, searchText)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(hits), hits, nil
+}
 
-	libraryMap := make(map[int]*models.Song)
-	for _, row := range libraryDB {
-		if _, exists := libraryMap[row.Id]; !exists {
-			libraryMap[row.Id] = &models.Song{
+// collectSongs folds song×group rows into songs keeping the order in which songs first appear
+func collectSongs(rows []models.SongDBFormat) []models.Song {
+	songs := []models.Song{}
+	indexes := make(map[int]int)
+	for _, row := range rows {
+		i, exists := indexes[row.Id]
+		if !exists {
+			i = len(songs)
+			indexes[row.Id] = i
+			songs = append(songs, models.Song{
 				Id:          row.Id,
 				Name:        row.Name,
 				ReleaseDate: row.ReleaseDate,
 				Link:        row.Link,
 				Groups:      []models.Group{},
-			}
+				Score:       row.Score,
+				DeletedAt:   row.DeletedAt,
+				Version:     row.Version,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+			})
 		}
-		libraryMap[row.Id].Groups = append(libraryMap[row.Id].Groups, models.Group{
+		if row.GroupId == 0 {
+			continue
+		}
+		songs[i].Groups = append(songs[i].Groups, models.Group{
 			Id:   row.GroupId,
 			Name: row.GroupName,
 		})
 	}
-
-	// Преобразование карты в список
-	var library []models.Song
-	for _, music := range libraryMap {
-		library = append(library, *music)
-	}
-
-	return len(library), library, nil
+	return songs
 }
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lrcTagPattern matches one tag at the beginning of an LRC line, e.g. [01:23.45] or [ar:Muse]
+var lrcTagPattern = regexp.MustCompile(`^\[([^\[\]]*)\]`)
+
+// lrcTimePattern matches the time of a time tag: minutes, seconds and an optional fraction of a second
+var lrcTimePattern = regexp.MustCompile(`^(\d+):(\d{1,2})(?:[.:](\d{1,3}))?$`)
+
+// lrcLine is a timed line of an LRC file
+type lrcLine struct {
+	timeMs int
+	text   string
+	// the line is the first one after a blank line of the file
+	paragraphStart bool
+}
+
+// parseLRC parses lyrics in the LRC format into verses. Blank lines of the file separate verses,
+// a timed line without text ends the current verse. A line may have several time tags,
+// the lines are ordered by time. Lines without a time tag are ignored
+func parseLRC(lrc string) ([]models.VerseDraft, error) {
+	var lines []lrcLine
+	offsetMs := 0
+	paragraphStart := true
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(lrc, "\ufeff")))
+	for scanner.Scan() {
+		rest := strings.TrimSpace(scanner.Text())
+		if rest == "" {
+			paragraphStart = true
+			continue
+		}
+
+		var times []int
+		for {
+			tag := lrcTagPattern.FindStringSubmatch(rest)
+			if tag == nil {
+				break
+			}
+			rest = rest[len(tag[0]):]
+			if match := lrcTimePattern.FindStringSubmatch(tag[1]); match != nil {
+				times = append(times, lrcTimeMs(match))
+				continue
+			}
+			key, value, _ := strings.Cut(tag[1], ":")
+			if strings.TrimSpace(key) == "offset" {
+				offset, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil {
+					return nil, fmt.Errorf("bad offset tag %q", tag[0])
+				}
+				offsetMs = offset
+			}
+		}
+		if len(times) == 0 {
+			continue
+		}
+
+		text := strings.TrimSpace(rest)
+		for _, t := range times {
+			lines = append(lines, lrcLine{timeMs: t, text: text, paragraphStart: paragraphStart})
+		}
+		paragraphStart = false
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no timed lines found")
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].timeMs < lines[j].timeMs
+	})
+
+	var verses []models.VerseDraft
+	var current *models.VerseDraft
+	var texts []string
+	closeVerse := func() {
+		if current == nil {
+			return
+		}
+		current.Text = strings.Join(texts, "\n")
+		verses = append(verses, *current)
+		current = nil
+		texts = nil
+	}
+	for _, line := range lines {
+		// a positive offset makes the lyrics appear sooner
+		timeMs := max(line.timeMs-offsetMs, 0)
+		if line.text == "" {
+			if current != nil {
+				current.EndMs = &timeMs
+			}
+			closeVerse()
+			continue
+		}
+		if line.paragraphStart {
+			closeVerse()
+		}
+		if current == nil {
+			current = &models.VerseDraft{Kind: models.VerseKindVerse, StartMs: &timeMs}
+		}
+		texts = append(texts, line.text)
+		current.LineStartsMs = append(current.LineStartsMs, timeMs)
+	}
+	closeVerse()
+
+	return markRepeats(verses), nil
+}
+
+// renderLRC renders the verses of a song in the LRC format
+func renderLRC(song models.Song, verses []models.Verse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[ti:%s]\n", song.Name)
+	if len(song.Groups) > 0 {
+		names := make([]string, 0, len(song.Groups))
+		for _, group := range song.Groups {
+			names = append(names, group.Name)
+		}
+		fmt.Fprintf(&b, "[ar:%s]\n", strings.Join(names, ", "))
+	}
+
+	for _, verse := range verses {
+		b.WriteString("\n")
+		for i, line := range strings.Split(verse.Text, "\n") {
+			switch {
+			case i < len(verse.LineStartsMs):
+				b.WriteString(lrcTime(verse.LineStartsMs[i]))
+			case i == 0 && verse.StartMs != nil:
+				b.WriteString(lrcTime(*verse.StartMs))
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		if verse.EndMs != nil {
+			b.WriteString(lrcTime(*verse.EndMs))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// lrcTimeMs converts a match of lrcTimePattern into milliseconds
+func lrcTimeMs(match []string) int {
+	minutes, _ := strconv.Atoi(match[1])
+	seconds, _ := strconv.Atoi(match[2])
+	ms := (minutes*60 + seconds) * 1000
+	if fraction := match[3]; fraction != "" {
+		value, _ := strconv.Atoi(fraction)
+		for i := len(fraction); i < 3; i++ {
+			value *= 10
+		}
+		ms += value
+	}
+	return ms
+}
+
+// lrcTime formats a time tag with hundredths of a second
+func lrcTime(ms int) string {
+	return fmt.Sprintf("[%02d:%02d.%02d]", ms/60000, ms/1000%60, ms%1000/10)
+}
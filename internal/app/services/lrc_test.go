@@ -0,0 +1,174 @@
+package services
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name    string
+		lrc     string
+		want    []models.VerseDraft
+		wantErr bool
+	}{
+		{
+			name: "timed lines",
+			lrc:  "[ti:Uprising]\n[00:01.00]Paranoia is in bloom\n[00:02.50]The PR transmissions will resume\n[00:04.00]\n",
+			want: []models.VerseDraft{{
+				Text:         "Paranoia is in bloom\nThe PR transmissions will resume",
+				Kind:         models.VerseKindVerse,
+				StartMs:      intPtr(1000),
+				EndMs:        intPtr(4000),
+				LineStartsMs: models.LineTimes{1000, 2500},
+			}},
+		},
+		{
+			name: "blank line separates verses",
+			lrc:  "[00:01.00]a\n\n[00:03.00]b\n",
+			want: []models.VerseDraft{
+				{Text: "a", Kind: models.VerseKindVerse, StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000}},
+				{Text: "b", Kind: models.VerseKindVerse, StartMs: intPtr(3000), LineStartsMs: models.LineTimes{3000}},
+			},
+		},
+		{
+			name: "several time tags",
+			lrc:  "[00:01.00]a\n[00:03.00][00:02.00]b\n",
+			want: []models.VerseDraft{{
+				Text:         "a\nb\nb",
+				Kind:         models.VerseKindVerse,
+				StartMs:      intPtr(1000),
+				LineStartsMs: models.LineTimes{1000, 2000, 3000},
+			}},
+		},
+		{
+			name: "fractions of a second",
+			lrc:  "[01:02]a\n[01:02.5]b\n[01:02:345]c\n",
+			want: []models.VerseDraft{{
+				Text:         "a\nc\nb",
+				Kind:         models.VerseKindVerse,
+				StartMs:      intPtr(62000),
+				LineStartsMs: models.LineTimes{62000, 62345, 62500},
+			}},
+		},
+		{
+			name: "offset",
+			lrc:  "[offset:500]\n[00:00.20]a\n[00:01.00]b\n",
+			want: []models.VerseDraft{{
+				Text:         "a\nb",
+				Kind:         models.VerseKindVerse,
+				StartMs:      intPtr(0),
+				LineStartsMs: models.LineTimes{0, 500},
+			}},
+		},
+		{
+			name: "repeated verse",
+			lrc:  "[00:01.00]x\n\n[00:02.00]y\n\n[00:03.00]x\n",
+			want: []models.VerseDraft{
+				{Text: "x", Kind: models.VerseKindChorus, StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000}},
+				{Text: "y", Kind: models.VerseKindVerse, StartMs: intPtr(2000), LineStartsMs: models.LineTimes{2000}},
+				{
+					Kind:         models.VerseKindChorus,
+					Repeats:      intPtr(0),
+					StartMs:      intPtr(3000),
+					LineStartsMs: models.LineTimes{3000},
+				},
+			},
+		},
+		{
+			name: "byte order mark and untimed lines",
+			lrc:  "\ufeff[ar:Muse]\nno time\n[00:01.00]a\n",
+			want: []models.VerseDraft{
+				{Text: "a", Kind: models.VerseKindVerse, StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000}},
+			},
+		},
+		{name: "no timed lines", lrc: "[ti:Uprising]\nParanoia is in bloom\n", wantErr: true},
+		{name: "bad offset", lrc: "[offset:soon]\n[00:01.00]a\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLRC(tt.lrc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLRC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLRC() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLRC(t *testing.T) {
+	song := models.Song{Name: "Uprising", Groups: []models.Group{{Id: 1, Name: "Muse"}, {Id: 2, Name: "Guest"}}}
+	verses := []models.Verse{
+		{
+			Text:         "Paranoia is in bloom\nThe PR transmissions will resume",
+			StartMs:      intPtr(1000),
+			EndMs:        intPtr(62340),
+			LineStartsMs: models.LineTimes{1000, 2500},
+		},
+		{Text: "They will not force us\nThey will stop degrading us", StartMs: intPtr(70000)},
+		{Text: "Untimed"},
+	}
+	want := "[ti:Uprising]\n[ar:Muse, Guest]\n" +
+		"\n[00:01.00]Paranoia is in bloom\n[00:02.50]The PR transmissions will resume\n[01:02.34]\n" +
+		"\n[01:10.00]They will not force us\nThey will stop degrading us\n" +
+		"\nUntimed\n"
+	if got := renderLRC(song, verses); got != want {
+		t.Errorf("renderLRC() = %q, want %q", got, want)
+	}
+}
+
+func TestLRCRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		verses []models.Verse
+	}{
+		{
+			name: "one verse",
+			verses: []models.Verse{
+				{Text: "a\nb", StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000, 2000}},
+			},
+		},
+		{
+			name: "verses with ends",
+			verses: []models.Verse{
+				{Text: "a\nb", StartMs: intPtr(1000), EndMs: intPtr(3000), LineStartsMs: models.LineTimes{1000, 2000}},
+				{Text: "c", StartMs: intPtr(65430), EndMs: intPtr(70000), LineStartsMs: models.LineTimes{65430}},
+			},
+		},
+		{
+			name: "verses without ends",
+			verses: []models.Verse{
+				{Text: "a", StartMs: intPtr(0), LineStartsMs: models.LineTimes{0}},
+				{Text: "b\nc\nd", StartMs: intPtr(600000), LineStartsMs: models.LineTimes{600000, 601230, 602000}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lrc := renderLRC(models.Song{Name: "Uprising"}, tt.verses)
+			drafts, err := parseLRC(lrc)
+			if err != nil {
+				t.Fatalf("parseLRC(%q) error = %v", lrc, err)
+			}
+			got := make([]models.Verse, 0, len(drafts))
+			for _, draft := range drafts {
+				got = append(got, models.Verse{
+					Text:         draft.Text,
+					StartMs:      draft.StartMs,
+					EndMs:        draft.EndMs,
+					LineStartsMs: draft.LineStartsMs,
+				})
+			}
+			if !reflect.DeepEqual(got, tt.verses) {
+				t.Errorf("round trip of %q = %+v, want %+v", lrc, got, tt.verses)
+			}
+		})
+	}
+}
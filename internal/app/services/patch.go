@@ -0,0 +1,339 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+const (
+	PatchTypeJSONPatch  = "application/json-patch+json"
+	PatchTypeMergePatch = "application/merge-patch+json"
+)
+
+// PatchSong applies a JSON Patch (RFC 6902) or a JSON Merge Patch (RFC 7386) to the document of the song
+// and turns the difference into the minimal set of operations, which are applied as by ChangeSong.
+// A failed test operation is a conflict. If version is not 0, the song must have this version.
+// The new version of the song is returned
+func (s *SongService) PatchSong(id int, actor models.Actor, version int, patchType string, patch []byte) (int, error) {
+	const op = "service.patch.PatchSong"
+	current, currentVersion, err := s.songDocument(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if version != 0 && version != currentVersion {
+		mlErr := errors.NewMusicLibraryError(errors.PreconditionFailedError,
+			fmt.Errorf("song %d has version %d, not %d", id, currentVersion, version))
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	original, err := json.Marshal(current)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.InternalError, err)
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	var patched []byte
+	switch patchType {
+	case PatchTypeJSONPatch:
+		var decoded jsonpatch.Patch
+		decoded, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = decoded.Apply(original)
+		}
+	case PatchTypeMergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	default:
+		err = fmt.Errorf("unsupported patch type %q", patchType)
+	}
+	if err != nil {
+		if stderrors.Is(err, jsonpatch.ErrTestFailed) {
+			mlErr := errors.NewMusicLibraryError(errors.ConflictError, err)
+			return 0, fmt.Errorf("%s: %w", op, mlErr)
+		}
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	var target models.SongDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&target); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("patched song is invalid: %w", err))
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+
+	operations, err := documentOperations(current, target)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	if len(operations) == 0 {
+		s.logger.Info("Song patch changes nothing", slog.Int("songId", id))
+		return currentVersion, nil
+	}
+
+	// the operations are computed against the document of currentVersion and must not be applied to another one
+	newVersion, err := s.ChangeSong(id, actor, currentVersion, operations)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return newVersion, nil
+}
+
+// songDocument returns the current document of the song and its version
+func (s *SongService) songDocument(id int) (models.SongDocument, int, error) {
+	songData, err := s.songRepository.GetSong(id)
+	if err != nil {
+		return models.SongDocument{}, 0, err
+	}
+	verses, err := s.songRepository.GetSongVerses(id)
+	if err != nil {
+		return models.SongDocument{}, 0, err
+	}
+	song := collectSongs(songData)[0]
+	return models.SongDocument{
+		Id:          song.Id,
+		Name:        song.Name,
+		ReleaseDate: song.ReleaseDate,
+		Link:        song.Link,
+		Groups:      song.Groups,
+		Verses:      verses,
+	}, song.Version, nil
+}
+
+// documentOperations returns the operations turning the current document into the target one.
+// Verses are deleted first, then moved, changed and finally the new verses are inserted
+func documentOperations(current models.SongDocument, target models.SongDocument) ([]models.SongOperation, error) {
+	if target.Id != current.Id {
+		return nil, fmt.Errorf("id is read-only")
+	}
+
+	var operations []models.SongOperation
+	if target.Name != current.Name {
+		operations = append(operations, models.SongOperation{Op: models.SongOpRename, Name: target.Name})
+	}
+	if target.Link != current.Link {
+		operations = append(operations, models.SongOperation{Op: models.SongOpSetLink, Link: target.Link})
+	}
+	if !target.ReleaseDate.Equal(current.ReleaseDate) {
+		if target.ReleaseDate.IsZero() {
+			return nil, fmt.Errorf("releaseDate can not be cleared")
+		}
+		operations = append(operations, models.SongOperation{
+			Op:          models.SongOpSetReleaseDate,
+			ReleaseDate: &models.Date{Time: target.ReleaseDate},
+		})
+	}
+
+	groupOperations, err := groupsOperations(current.Groups, target.Groups)
+	if err != nil {
+		return nil, err
+	}
+	operations = append(operations, groupOperations...)
+
+	verseOperations, err := versesOperations(current.Verses, target.Verses)
+	if err != nil {
+		return nil, err
+	}
+	return append(operations, verseOperations...), nil
+}
+
+// groupsOperations matches groups by id. Groups without id are added by name,
+// groups can not be renamed through a song
+func groupsOperations(current []models.Group, target []models.Group) ([]models.SongOperation, error) {
+	currentNames := make(map[int]string, len(current))
+	for _, group := range current {
+		currentNames[group.Id] = group.Name
+	}
+
+	var operations []models.SongOperation
+	kept := make(map[int]bool)
+	for i, group := range target {
+		if group.Id == 0 {
+			if group.Name == "" {
+				return nil, fmt.Errorf("group %d has neither id nor name", i)
+			}
+			operations = append(operations, models.SongOperation{Op: models.SongOpAddGroup, Name: group.Name})
+			continue
+		}
+		name, ok := currentNames[group.Id]
+		if !ok {
+			return nil, fmt.Errorf("group %d is not a group of the song, add groups by name", group.Id)
+		}
+		if group.Name != name {
+			return nil, fmt.Errorf("name of group %d is read-only, rename the group itself", group.Id)
+		}
+		kept[group.Id] = true
+	}
+	for _, group := range current {
+		if !kept[group.Id] {
+			operations = append(operations, models.SongOperation{Op: models.SongOpRemoveGroup, GroupId: group.Id})
+		}
+	}
+	return operations, nil
+}
+
+// versesOperations matches verses by id. Verses without id are inserted
+func versesOperations(current []models.Verse, target []models.Verse) ([]models.SongOperation, error) {
+	currentVerses := make(map[int]models.Verse, len(current))
+	positions := make(map[int]int, len(current))
+	for i, verse := range current {
+		currentVerses[verse.Id] = verse
+		positions[verse.Id] = i
+	}
+
+	seen := make(map[int]bool)
+	var keptPositions []int
+	for i, verse := range target {
+		if verse.Id == 0 {
+			if verse.StartMs != nil || verse.EndMs != nil || verse.LineStartsMs != nil {
+				return nil, fmt.Errorf("timings of verse %d are read-only, import the lyrics instead", i)
+			}
+			continue
+		}
+		old, ok := currentVerses[verse.Id]
+		if !ok {
+			return nil, fmt.Errorf("verse %d is not a verse of the song, ids of verses are read-only", verse.Id)
+		}
+		if seen[verse.Id] {
+			return nil, fmt.Errorf("verse %d occurs more than once", verse.Id)
+		}
+		seen[verse.Id] = true
+		if !reflect.DeepEqual(verse.StartMs, old.StartMs) || !reflect.DeepEqual(verse.EndMs, old.EndMs) ||
+			!reflect.DeepEqual(verse.LineStartsMs, old.LineStartsMs) {
+			return nil, fmt.Errorf("timings of verse %d are read-only, import the lyrics instead", verse.Id)
+		}
+		keptPositions = append(keptPositions, positions[verse.Id])
+	}
+
+	var operations []models.SongOperation
+	for _, verse := range current {
+		if !seen[verse.Id] {
+			operations = append(operations, models.SongOperation{Op: models.SongOpDeleteVerse, VerseId: verse.Id})
+		}
+	}
+
+	// the verses on the longest increasing subsequence of the old positions stay in place, the others are moved
+	// right after the verse preceding them in the target
+	inPlace := longestIncreasing(keptPositions)
+	prevId := 0
+	for _, verse := range target {
+		if verse.Id == 0 {
+			continue
+		}
+		if !inPlace[positions[verse.Id]] {
+			operations = append(operations, models.SongOperation{
+				Op: models.SongOpMoveVerse, VerseId: verse.Id, AfterVerseId: prevId,
+			})
+		}
+		prevId = verse.Id
+	}
+
+	for i, verse := range target {
+		if verse.Id == 0 {
+			continue
+		}
+		operation, changed, err := verseChange(currentVerses[verse.Id], verse)
+		if err != nil {
+			return nil, fmt.Errorf("verse %d: %w", i, err)
+		}
+		if changed {
+			operations = append(operations, operation)
+		}
+	}
+
+	prevId = 0
+	for i, verse := range target {
+		if verse.Id != 0 {
+			prevId = verse.Id
+			continue
+		}
+		if verse.RepeatOf == nil && verse.Text == "" {
+			return nil, fmt.Errorf("verse %d has neither text nor repeatOf", i)
+		}
+		operations = append(operations, models.SongOperation{
+			Op:           models.SongOpInsertVerse,
+			AfterVerseId: prevId,
+			Text:         verse.Text,
+			Kind:         verse.Kind,
+			Label:        verse.Label,
+			RepeatOf:     verse.RepeatOf,
+		})
+	}
+	return operations, nil
+}
+
+// verseChange returns the operation changing the old verse into the new one and whether anything changed
+func verseChange(old models.Verse, verse models.Verse) (models.SongOperation, bool, error) {
+	operation := models.SongOperation{Op: models.SongOpUpdateVerse, VerseId: verse.Id}
+	changed := false
+	if verse.Kind != old.Kind {
+		if verse.Kind == "" {
+			return operation, false, fmt.Errorf("kind can not be cleared")
+		}
+		operation.Kind, changed = verse.Kind, true
+	}
+	if verse.Label != old.Label {
+		if verse.Label == "" {
+			return operation, false, fmt.Errorf("label can not be cleared")
+		}
+		operation.Label, changed = verse.Label, true
+	}
+
+	switch {
+	case verse.RepeatOf != nil:
+		// the text of a repeat is the text of the repeated verse
+		if old.RepeatOf == nil || *old.RepeatOf != *verse.RepeatOf {
+			operation.RepeatOf, changed = verse.RepeatOf, true
+		}
+	case old.RepeatOf != nil:
+		stop := 0
+		operation.RepeatOf, changed = &stop, true
+		if verse.Text != old.Text {
+			operation.Text = verse.Text
+		}
+	case verse.Text != old.Text:
+		if verse.Text == "" {
+			return operation, false, fmt.Errorf("text can not be cleared, delete the verse instead")
+		}
+		operation.Text, changed = verse.Text, true
+	}
+	return operation, changed, nil
+}
+
+// longestIncreasing returns the values forming the longest increasing subsequence of the values
+func longestIncreasing(values []int) map[int]bool {
+	// tails[k] is the index of the smallest tail of an increasing subsequence of length k+1
+	var tails []int
+	prev := make([]int, len(values))
+	for i, value := range values {
+		k := sort.Search(len(tails), func(j int) bool {
+			return values[tails[j]] >= value
+		})
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	result := make(map[int]bool, len(tails))
+	if len(tails) == 0 {
+		return result
+	}
+	for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+		result[values[i]] = true
+	}
+	return result
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDocumentOperations(t *testing.T) {
+	releaseDate := time.Date(2009, 9, 14, 0, 0, 0, 0, time.UTC)
+	current := models.SongDocument{
+		Id:          458,
+		Name:        "Uprising",
+		ReleaseDate: releaseDate,
+		Link:        "https://example.com/uprising",
+		Groups:      []models.Group{{Id: 1, Name: "Muse"}, {Id: 2, Name: "Guest"}},
+		Verses:      []models.Verse{{Id: 10, Kind: models.VerseKindVerse, Text: "a"}},
+	}
+	tests := []struct {
+		name    string
+		change  func(doc *models.SongDocument)
+		want    []models.SongOperation
+		wantErr bool
+	}{
+		{name: "unchanged", change: func(doc *models.SongDocument) {}},
+		{
+			name: "fields",
+			change: func(doc *models.SongDocument) {
+				doc.Name = "Resistance"
+				doc.Link = "https://example.com/resistance"
+				doc.ReleaseDate = releaseDate.AddDate(0, 0, 1)
+			},
+			want: []models.SongOperation{
+				{Op: models.SongOpRename, Name: "Resistance"},
+				{Op: models.SongOpSetLink, Link: "https://example.com/resistance"},
+				{Op: models.SongOpSetReleaseDate, ReleaseDate: &models.Date{Time: releaseDate.AddDate(0, 0, 1)}},
+			},
+		},
+		{
+			name: "same release date in another zone",
+			change: func(doc *models.SongDocument) {
+				doc.ReleaseDate = releaseDate.In(time.FixedZone("UTC+3", 3*60*60))
+			},
+		},
+		{
+			name: "groups",
+			change: func(doc *models.SongDocument) {
+				doc.Groups = []models.Group{{Id: 1, Name: "Muse"}, {Name: "Other"}}
+			},
+			want: []models.SongOperation{
+				{Op: models.SongOpAddGroup, Name: "Other"},
+				{Op: models.SongOpRemoveGroup, GroupId: 2},
+			},
+		},
+		{
+			name: "fields come before verses",
+			change: func(doc *models.SongDocument) {
+				doc.Name = "Resistance"
+				doc.Verses = []models.Verse{{Id: 10, Kind: models.VerseKindVerse, Text: "b"}}
+			},
+			want: []models.SongOperation{
+				{Op: models.SongOpRename, Name: "Resistance"},
+				{Op: models.SongOpUpdateVerse, VerseId: 10, Text: "b"},
+			},
+		},
+		{name: "id", change: func(doc *models.SongDocument) { doc.Id = 459 }, wantErr: true},
+		{
+			name:    "cleared release date",
+			change:  func(doc *models.SongDocument) { doc.ReleaseDate = time.Time{} },
+			wantErr: true,
+		},
+		{
+			name:    "renamed group",
+			change:  func(doc *models.SongDocument) { doc.Groups = []models.Group{{Id: 1, Name: "MUSE"}} },
+			wantErr: true,
+		},
+		{
+			name:    "group of another song",
+			change:  func(doc *models.SongDocument) { doc.Groups = []models.Group{{Id: 3, Name: "Other"}} },
+			wantErr: true,
+		},
+		{
+			name:    "group without id and name",
+			change:  func(doc *models.SongDocument) { doc.Groups = []models.Group{{}} },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := current
+			target.Groups = append([]models.Group(nil), current.Groups...)
+			target.Verses = append([]models.Verse(nil), current.Verses...)
+			tt.change(&target)
+
+			got, err := documentOperations(current, target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("documentOperations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("documentOperations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersesOperations(t *testing.T) {
+	current := []models.Verse{
+		{Id: 1, Kind: models.VerseKindVerse, Text: "a"},
+		{Id: 2, Kind: models.VerseKindChorus, Text: "b", StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000}},
+		{Id: 3, Kind: models.VerseKindVerse, Text: "c"},
+		{Id: 4, Kind: models.VerseKindChorus, RepeatOf: intPtr(2)},
+	}
+	tests := []struct {
+		name    string
+		target  []models.Verse
+		want    []models.SongOperation
+		wantErr bool
+	}{
+		{name: "unchanged", target: current},
+		{
+			name:   "delete",
+			target: []models.Verse{current[0], current[2], current[3]},
+			want:   []models.SongOperation{{Op: models.SongOpDeleteVerse, VerseId: 2}},
+		},
+		{
+			name:   "move to the start",
+			target: []models.Verse{current[2], current[0], current[1], current[3]},
+			want:   []models.SongOperation{{Op: models.SongOpMoveVerse, VerseId: 3}},
+		},
+		{
+			name:   "move to the end",
+			target: []models.Verse{current[1], current[2], current[3], current[0]},
+			want:   []models.SongOperation{{Op: models.SongOpMoveVerse, VerseId: 1, AfterVerseId: 4}},
+		},
+		{
+			name: "insert",
+			target: []models.Verse{
+				{Kind: models.VerseKindIntro, Text: "intro"},
+				current[0], current[1],
+				{Text: "bridge", Label: "Bridge"},
+				current[2], current[3],
+				{RepeatOf: intPtr(2)},
+			},
+			want: []models.SongOperation{
+				{Op: models.SongOpInsertVerse, Kind: models.VerseKindIntro, Text: "intro"},
+				{Op: models.SongOpInsertVerse, AfterVerseId: 2, Text: "bridge", Label: "Bridge"},
+				{Op: models.SongOpInsertVerse, AfterVerseId: 4, RepeatOf: intPtr(2)},
+			},
+		},
+		{
+			name: "update",
+			target: []models.Verse{
+				{Id: 1, Kind: models.VerseKindChorus, Label: "Chorus", Text: "a"},
+				{Id: 2, Kind: models.VerseKindChorus, Text: "B", StartMs: intPtr(1000), LineStartsMs: models.LineTimes{1000}},
+				{Id: 3, Kind: models.VerseKindVerse, RepeatOf: intPtr(1)},
+				{Id: 4, Kind: models.VerseKindChorus, Text: "own text"},
+			},
+			want: []models.SongOperation{
+				{Op: models.SongOpUpdateVerse, VerseId: 1, Kind: models.VerseKindChorus, Label: "Chorus"},
+				{Op: models.SongOpUpdateVerse, VerseId: 2, Text: "B"},
+				{Op: models.SongOpUpdateVerse, VerseId: 3, RepeatOf: intPtr(1)},
+				{Op: models.SongOpUpdateVerse, VerseId: 4, Text: "own text", RepeatOf: intPtr(0)},
+			},
+		},
+		{
+			name: "delete, move, update and insert",
+			target: []models.Verse{
+				current[1],
+				{Text: "new"},
+				{Id: 1, Kind: models.VerseKindVerse, Text: "A"},
+			},
+			want: []models.SongOperation{
+				{Op: models.SongOpDeleteVerse, VerseId: 3},
+				{Op: models.SongOpDeleteVerse, VerseId: 4},
+				{Op: models.SongOpMoveVerse, VerseId: 2},
+				{Op: models.SongOpUpdateVerse, VerseId: 1, Text: "A"},
+				{Op: models.SongOpInsertVerse, AfterVerseId: 2, Text: "new"},
+			},
+		},
+		{
+			name:    "unknown verse",
+			target:  []models.Verse{{Id: 9, Kind: models.VerseKindVerse, Text: "x"}},
+			wantErr: true,
+		},
+		{name: "duplicate verse", target: []models.Verse{current[0], current[0]}, wantErr: true},
+		{
+			name:    "changed timings",
+			target:  []models.Verse{{Id: 2, Kind: models.VerseKindChorus, Text: "b", StartMs: intPtr(2000)}},
+			wantErr: true,
+		},
+		{name: "new verse with timings", target: []models.Verse{{Text: "x", StartMs: intPtr(0)}}, wantErr: true},
+		{name: "new verse without text", target: []models.Verse{{Kind: models.VerseKindVerse}}, wantErr: true},
+		{name: "cleared kind", target: []models.Verse{{Id: 1, Text: "a"}}, wantErr: true},
+		{name: "cleared text", target: []models.Verse{{Id: 1, Kind: models.VerseKindVerse}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := versesOperations(current, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("versesOperations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versesOperations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   map[int]bool
+	}{
+		{name: "empty", want: map[int]bool{}},
+		{name: "sorted", values: []int{0, 1, 2}, want: map[int]bool{0: true, 1: true, 2: true}},
+		{name: "first moved to the end", values: []int{1, 2, 3, 0}, want: map[int]bool{1: true, 2: true, 3: true}},
+		{name: "last moved to the start", values: []int{3, 0, 1, 2}, want: map[int]bool{0: true, 1: true, 2: true}},
+		{name: "reversed", values: []int{2, 1, 0}, want: map[int]bool{0: true}},
+		{name: "swapped pairs", values: []int{1, 0, 3, 2}, want: map[int]bool{0: true, 2: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longestIncreasing(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("longestIncreasing(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
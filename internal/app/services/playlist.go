@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"strings"
+)
+
+const (
+	PlaylistFormatM3U  = "m3u"
+	PlaylistFormatXSPF = "xspf"
+)
+
+// playlistExportBatchSize is the number of items read from the db at once during an export
+const playlistExportBatchSize = 500
+
+type PlaylistRepository interface {
+	GetPlaylists(limit int, offset int) ([]models.Playlist, error)
+	GetPlaylist(id int) (models.Playlist, error)
+	GetPlaylistItems(id int, limit int, offset int) ([]models.PlaylistItemDBFormat, error)
+	AddPlaylist(name string, actor models.Actor) (int, error)
+	RenamePlaylist(id int, name string, actor models.Actor) error
+	DeletePlaylist(id int, actor models.Actor) error
+	AddItem(id int, songId int, position int, actor models.Actor) (int, error)
+	MoveItem(id int, itemId int, position int, actor models.Actor) error
+	DeleteItem(id int, itemId int, actor models.Actor) error
+}
+
+type PlaylistService struct {
+	logger             *slog.Logger
+	playlistRepository PlaylistRepository
+}
+
+func NewPlaylistService(logger *slog.Logger, p PlaylistRepository) *PlaylistService {
+	return &PlaylistService{
+		logger:             logger,
+		playlistRepository: p,
+	}
+}
+
+func (p *PlaylistService) GetPlaylists(limit int, page int) (int, []models.Playlist, error) {
+	const op = "service.playlist.GetPlaylists"
+	offset := page * limit
+	playlists, err := p.playlistRepository.GetPlaylists(limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(playlists), playlists, nil
+}
+
+func (p *PlaylistService) GetPlaylist(id int) (models.Playlist, error) {
+	const op = "service.playlist.GetPlaylist"
+	playlist, err := p.playlistRepository.GetPlaylist(id)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return playlist, nil
+}
+
+// GetPlaylistItems returns a page of the items of the playlist in order
+func (p *PlaylistService) GetPlaylistItems(id int, limit int, page int) (int, []models.PlaylistItem, error) {
+	const op = "service.playlist.GetPlaylistItems"
+	offset := page * limit
+	// an unknown playlist is not found rather than empty
+	if _, err := p.playlistRepository.GetPlaylist(id); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := p.playlistRepository.GetPlaylistItems(id, limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	items := collectPlaylistItems(rows)
+	return len(items), items, nil
+}
+
+func (p *PlaylistService) AddPlaylist(name string, actor models.Actor) (int, error) {
+	const op = "service.playlist.AddPlaylist"
+	id, err := p.playlistRepository.AddPlaylist(name, actor)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	p.logger.Info("Playlist added", slog.Int("playlistId", id), slog.String("name", name))
+	return id, nil
+}
+
+func (p *PlaylistService) RenamePlaylist(id int, name string, actor models.Actor) error {
+	const op = "service.playlist.RenamePlaylist"
+	err := p.playlistRepository.RenamePlaylist(id, name, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	p.logger.Info("Playlist renamed", slog.Int("playlistId", id), slog.String("name", name))
+	return nil
+}
+
+func (p *PlaylistService) DeletePlaylist(id int, actor models.Actor) error {
+	const op = "service.playlist.DeletePlaylist"
+	err := p.playlistRepository.DeletePlaylist(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	p.logger.Info("Playlist deleted", slog.Int("playlistId", id))
+	return nil
+}
+
+// AddItem puts the song at the position of the playlist and returns the id of the item,
+// position 0 appends the song
+func (p *PlaylistService) AddItem(id int, songId int, position int, actor models.Actor) (int, error) {
+	const op = "service.playlist.AddItem"
+	itemId, err := p.playlistRepository.AddItem(id, songId, position, actor)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	p.logger.Info("Song added to playlist", slog.Int("playlistId", id), slog.Int("songId", songId),
+		slog.Int("itemId", itemId))
+	return itemId, nil
+}
+
+func (p *PlaylistService) MoveItem(id int, itemId int, position int, actor models.Actor) error {
+	const op = "service.playlist.MoveItem"
+	err := p.playlistRepository.MoveItem(id, itemId, position, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	p.logger.Info("Playlist item moved", slog.Int("playlistId", id), slog.Int("itemId", itemId),
+		slog.Int("position", position))
+	return nil
+}
+
+func (p *PlaylistService) DeleteItem(id int, itemId int, actor models.Actor) error {
+	const op = "service.playlist.DeleteItem"
+	err := p.playlistRepository.DeleteItem(id, itemId, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	p.logger.Info("Playlist item deleted", slog.Int("playlistId", id), slog.Int("itemId", itemId))
+	return nil
+}
+
+// ExportPlaylist returns the playlist as an M3U or XSPF file with the links of its songs
+func (p *PlaylistService) ExportPlaylist(id int, format string) ([]byte, error) {
+	const op = "service.playlist.ExportPlaylist"
+	if format != PlaylistFormatM3U && format != PlaylistFormatXSPF {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, fmt.Errorf("unknown playlist format %q", format))
+		return nil, fmt.Errorf("%s: %w", op, mlErr)
+	}
+	playlist, err := p.playlistRepository.GetPlaylist(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var items []models.PlaylistItem
+	for offset := 0; ; offset += playlistExportBatchSize {
+		rows, err := p.playlistRepository.GetPlaylistItems(id, playlistExportBatchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		batch := collectPlaylistItems(rows)
+		items = append(items, batch...)
+		if len(batch) < playlistExportBatchSize {
+			break
+		}
+	}
+
+	var file []byte
+	switch format {
+	case PlaylistFormatM3U:
+		file = playlistM3U(playlist, items)
+	case PlaylistFormatXSPF:
+		file, err = playlistXSPF(playlist, items)
+		if err != nil {
+			mlErr := errors.NewMusicLibraryError(errors.InternalError, err)
+			return nil, fmt.Errorf("%s: %w", op, mlErr)
+		}
+	}
+	p.logger.Info("Playlist exported", slog.Int("playlistId", id), slog.String("format", format))
+	return file, nil
+}
+
+// collectPlaylistItems folds item×group rows into items keeping their order
+func collectPlaylistItems(rows []models.PlaylistItemDBFormat) []models.PlaylistItem {
+	items := []models.PlaylistItem{}
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].ItemId == rows[start].ItemId {
+			end++
+		}
+		songRows := make([]models.SongDBFormat, 0, end-start)
+		for _, row := range rows[start:end] {
+			songRows = append(songRows, row.SongDBFormat)
+		}
+		items = append(items, models.PlaylistItem{
+			Id:       rows[start].ItemId,
+			Position: rows[start].Position,
+			Song:     collectSongs(songRows)[0],
+		})
+		start = end
+	}
+	return items
+}
+
+// songCreator joins the names of the groups of the song
+func songCreator(song models.Song) string {
+	names := make([]string, 0, len(song.Groups))
+	for _, group := range song.Groups {
+		names = append(names, group.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// playlistM3U writes an extended M3U playlist. Songs without a link can not be played and are left out
+func playlistM3U(playlist models.Playlist, items []models.PlaylistItem) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	// names are written on a single line
+	newLines := strings.NewReplacer("\r", " ", "\n", " ")
+	fmt.Fprintf(&buf, "#PLAYLIST:%s\n", newLines.Replace(playlist.Name))
+	for _, item := range items {
+		if item.Song.Link == "" {
+			continue
+		}
+		title := item.Song.Name
+		if creator := songCreator(item.Song); creator != "" {
+			title = creator + " - " + title
+		}
+		fmt.Fprintf(&buf, "#EXTINF:-1,%s\n%s\n", newLines.Replace(title), item.Song.Link)
+	}
+	return buf.Bytes()
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string   `xml:"version,attr"`
+	Title   string   `xml:"title"`
+	// trackList is required even if there are no tracks
+	TrackList struct {
+		Tracks []xspfTrack `xml:"track"`
+	} `xml:"trackList"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator,omitempty"`
+}
+
+// playlistXSPF writes an XSPF playlist, songs without a link are kept without a location
+func playlistXSPF(playlist models.Playlist, items []models.PlaylistItem) ([]byte, error) {
+	document := xspfPlaylist{
+		Version: "1",
+		Title:   playlist.Name,
+	}
+	for _, item := range items {
+		document.TrackList.Tracks = append(document.TrackList.Tracks, xspfTrack{
+			Location: item.Song.Link,
+			Title:    item.Song.Name,
+			Creator:  songCreator(item.Song),
+		})
+	}
+	file, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(file, '\n')...), nil
+}
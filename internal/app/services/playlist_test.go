@@ -0,0 +1,98 @@
+package services
+
+import (
+	"github.com/nosikmy/music-library/internal/app/models"
+	"testing"
+)
+
+// playlistItems are a song with two groups, a song without a link and a song without groups
+var playlistItems = []models.PlaylistItem{
+	{Id: 1, Position: 1, Song: models.Song{
+		Name: "Uprising", Link: "https://example.com/uprising",
+		Groups: []models.Group{{Id: 1, Name: "Muse"}, {Id: 2, Name: "Guest"}},
+	}},
+	{Id: 2, Position: 2, Song: models.Song{Name: "Resistance", Groups: []models.Group{{Id: 1, Name: "Muse"}}}},
+	{Id: 3, Position: 3, Song: models.Song{Name: "Exo & \"genesis\"", Link: "https://example.com/exo?a=1&b=2"}},
+}
+
+func TestPlaylistM3U(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist models.Playlist
+		items    []models.PlaylistItem
+		want     string
+	}{
+		{name: "empty", playlist: models.Playlist{Name: "Road trip"}, want: "#EXTM3U\n#PLAYLIST:Road trip\n"},
+		{
+			name:     "songs",
+			playlist: models.Playlist{Name: "Road\ntrip"},
+			items:    playlistItems,
+			want: "#EXTM3U\n#PLAYLIST:Road trip\n" +
+				"#EXTINF:-1,Muse, Guest - Uprising\nhttps://example.com/uprising\n" +
+				"#EXTINF:-1,Exo & \"genesis\"\nhttps://example.com/exo?a=1&b=2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(playlistM3U(tt.playlist, tt.items)); got != tt.want {
+				t.Errorf("playlistM3U() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaylistXSPF(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist models.Playlist
+		items    []models.PlaylistItem
+		want     string
+	}{
+		{
+			name:     "empty",
+			playlist: models.Playlist{Name: "Road trip"},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" version="1">
+  <title>Road trip</title>
+  <trackList></trackList>
+</playlist>
+`,
+		},
+		{
+			name:     "songs",
+			playlist: models.Playlist{Name: "Rock & roll"},
+			items:    playlistItems,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" version="1">
+  <title>Rock &amp; roll</title>
+  <trackList>
+    <track>
+      <location>https://example.com/uprising</location>
+      <title>Uprising</title>
+      <creator>Muse, Guest</creator>
+    </track>
+    <track>
+      <title>Resistance</title>
+      <creator>Muse</creator>
+    </track>
+    <track>
+      <location>https://example.com/exo?a=1&amp;b=2</location>
+      <title>Exo &amp; &#34;genesis&#34;</title>
+    </track>
+  </trackList>
+</playlist>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := playlistXSPF(tt.playlist, tt.items)
+			if err != nil {
+				t.Fatalf("playlistXSPF() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("playlistXSPF() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,126 +1,287 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
 	"github.com/nosikmy/music-library/internal/app/models"
 	"log/slog"
 	"strings"
 	"time"
 )
 
+const similarGroupsLimit = 5
+
 type SongRepository interface {
-	GetSongText(id int, limit int, offset int) (int, []models.Verse, error)
-	DeleteSong(id int) error
-	AddSong(group string, song string, releaseDate time.Time, verses []string, link string) (int, error)
+	GetSongText(id int, limit int, offset int, cursor *models.Cursor) (int, []models.Verse, *models.Cursor, error)
+	DeleteSong(id int, version int, actor models.Actor) error
+	AddSong(groups []string, song string, releaseDate *time.Time, verses []models.VerseDraft, link string,
+		actor models.Actor) (int, bool, error)
+	GetSimilarGroups(group string, limit int) ([]models.Group, error)
+	GetSong(id int) ([]models.SongDBFormat, error)
+	GetSongVerses(id int) ([]models.Verse, error)
+	ImportVerses(id int, version int, verses []models.VerseDraft, actor models.Actor) (int, error)
+	GetSongVersion(id int) (int, error)
 }
 
-type SongChangerRepository interface {
-	ChangeSongName(id int, newName string) error
-	AddGroupToSong(id int, group string) error
-	DeleteGroupFromSong(id int, groupId int) error
+// MetadataProvider gets the metadata of a song from an external source
+type MetadataProvider interface {
+	GetSongInfo(ctx context.Context, group string, song string) (models.ApiMusicResponse, error)
 }
 
-type VersesRepository interface {
-	AddVerse(id int, newVerse *models.Verse) error
-	ChangeVerse(changeVerse *models.Verse) error
-	DeleteVerse(id int, verseId int) error
+type SongChangerRepository interface {
+	UpdateSong(id int, version int, operations []models.SongOperation, actor models.Actor) (int, int, error)
+	RevertSong(id int, version int, operations []models.SongOperation, verses []models.VerseDraft,
+		actor models.Actor) (int, int, error)
 }
 
 type SongService struct {
 	logger                *slog.Logger
 	songRepository        SongRepository
 	songChangerRepository SongChangerRepository
-	versesRepository      VersesRepository
+	revisionRepository    RevisionRepository
+	metadataProvider      MetadataProvider
 }
 
-func NewSongService(logger *slog.Logger, s SongRepository, sc SongChangerRepository, v VersesRepository) *SongService {
+func NewSongService(logger *slog.Logger, s SongRepository, sc SongChangerRepository, r RevisionRepository,
+	m MetadataProvider) *SongService {
 	return &SongService{
 		logger:                logger,
 		songRepository:        s,
 		songChangerRepository: sc,
-		versesRepository:      v,
+		revisionRepository:    r,
+		metadataProvider:      m,
 	}
 }
 
-func (s *SongService) GetSongText(id int, limit int, page int) (int, []models.Verse, error) {
+// GetSongText returns a page of verses and the cursor of the next page.
+// The page starts after the cursor if it is set, otherwise page is used
+func (s *SongService) GetSongText(id int, limit int, page int, cursor string) (int, []models.Verse, string, error) {
 	const op = "service.song.GetSongText"
 	offset := limit * page
-	count, song, err := s.songRepository.GetSongText(id, limit, offset)
+	after, err := decodeCursor(cursor)
 	if err != nil {
-		return 0, nil, fmt.Errorf("%s: %w", op, err)
+		return 0, nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	count, song, next, err := s.songRepository.GetSongText(id, limit, offset, after)
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, song, encodeCursor(next), nil
+}
+
+// GetSong returns the song with the number of its verses. If includeText is set, the verses are embedded in order
+func (s *SongService) GetSong(id int, includeText bool) (models.Song, error) {
+	const op = "service.song.GetSong"
+	songData, err := s.songRepository.GetSong(id)
+	if err != nil {
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	verses, err := s.songRepository.GetSongVerses(id)
+	if err != nil {
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	song := collectSongs(songData)[0]
+	versesCount := len(verses)
+	song.VersesCount = &versesCount
+	if includeText {
+		song.Text = verses
+	}
+	return song, nil
+}
+
+// GetSongVersion returns the current version of the song
+func (s *SongService) GetSongVersion(id int) (int, error) {
+	const op = "service.song.GetSongVersion"
+	version, err := s.songRepository.GetSongVersion(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return version, nil
+}
+
+// GetSongLRC returns the name of the song and its lyrics in the LRC format
+func (s *SongService) GetSongLRC(id int) (string, string, error) {
+	const op = "service.song.GetSongLRC"
+	songData, err := s.songRepository.GetSong(id)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	verses, err := s.songRepository.GetSongVerses(id)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	song := collectSongs(songData)[0]
+	return song.Name, renderLRC(song, verses), nil
+}
+
+// ImportLyrics replaces the lyrics of the song with time-synced lyrics in the LRC format
+// and returns the number of verses and the new version of the song.
+// If version is not 0, the song must have this version
+func (s *SongService) ImportLyrics(id int, version int, lrc string, actor models.Actor) (int, int, error) {
+	const op = "service.song.ImportLyrics"
+	verses, err := parseLRC(lrc)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return 0, 0, fmt.Errorf("%s: %w", op, mlErr)
 	}
 
-	return count, song, nil
+	newVersion, err := s.songRepository.ImportVerses(id, version, verses, actor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	s.logger.Info("Song lyrics replaced", slog.Int("songId", id), slog.Int("versesCount", len(verses)),
+		slog.Int("version", newVersion))
+	return len(verses), newVersion, nil
 }
 
-func (s *SongService) DeleteSong(id int) error {
+// DeleteSong moves the song to the trash. If version is not 0, the song must have this version
+func (s *SongService) DeleteSong(id int, version int, actor models.Actor) error {
 	const op = "service.song.DeleteSong"
-	err := s.songRepository.DeleteSong(id)
+	err := s.songRepository.DeleteSong(id, version, actor)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	s.logger.Info("Song moved to trash", slog.Int("songId", id))
 	return nil
 }
 
-func (s *SongService) ChangeSong(id int, changeName string, newGroup string, deleteGroupId int,
-	newVerse *models.Verse, changeVerse *models.Verse, deleteVerseId int) error {
+// ChangeSong applies the operations to the song atomically and records them as a revision.
+// If version is not 0, the song must have this version. The new version of the song is returned
+func (s *SongService) ChangeSong(id int, actor models.Actor, version int, operations []models.SongOperation) (int, error) {
 	const op = "service.song.ChangeSong"
-	if changeName != "" {
-		err := s.songChangerRepository.ChangeSongName(id, changeName)
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
-		}
-		s.logger.Info("Song name updated", slog.Int("songId", id), slog.String("newName", changeName))
+	if err := validateOperations(operations); err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError, err)
+		return 0, fmt.Errorf("%s: %w", op, mlErr)
 	}
-	if newGroup != "" {
-		err := s.songChangerRepository.AddGroupToSong(id, newGroup)
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
-		}
-		s.logger.Info("Added new group to song", slog.Int("songId", id), slog.String("newGroup", newGroup))
+
+	newVersion, revision, err := s.songChangerRepository.UpdateSong(id, version, operations, actor)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	if deleteGroupId != 0 {
-		err := s.songChangerRepository.DeleteGroupFromSong(id, deleteGroupId)
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
-		}
-		s.logger.Info("Song changed", slog.Int("songId", id), slog.Int("deletedGroupId", deleteGroupId))
+	s.logger.Info("Song changed", slog.Int("songId", id), slog.Int("operationsCount", len(operations)),
+		slog.Int("version", newVersion), slog.Int("revision", revision), slog.String("editor", actor.Name))
+	return newVersion, nil
+}
+
+// validateOperations checks that every operation has the fields it needs
+func validateOperations(operations []models.SongOperation) error {
+	if len(operations) == 0 {
+		return fmt.Errorf("no operations")
 	}
-	if newVerse != nil {
-		err := s.versesRepository.AddVerse(id, newVerse)
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+	for i, operation := range operations {
+		var err error
+		switch operation.Op {
+		case models.SongOpRename, models.SongOpAddGroup:
+			if operation.Name == "" {
+				err = fmt.Errorf("name is required")
+			}
+		case models.SongOpRemoveGroup:
+			if operation.GroupId <= 0 {
+				err = fmt.Errorf("groupId is required")
+			}
+		case models.SongOpSetLink:
+		case models.SongOpSetReleaseDate:
+			if operation.ReleaseDate == nil {
+				err = fmt.Errorf("releaseDate is required")
+			}
+		case models.SongOpInsertVerse:
+			if operation.AfterVerseId < 0 {
+				err = fmt.Errorf("afterVerseId must not be negative")
+			}
+		case models.SongOpUpdateVerse, models.SongOpDeleteVerse:
+			if operation.VerseId <= 0 {
+				err = fmt.Errorf("verseId is required")
+			}
+		case models.SongOpMoveVerse:
+			if operation.VerseId <= 0 {
+				err = fmt.Errorf("verseId is required")
+			} else if operation.AfterVerseId < 0 {
+				err = fmt.Errorf("afterVerseId must not be negative")
+			}
+		default:
+			err = fmt.Errorf("unknown operation")
 		}
-		s.logger.Info("Added new verse to song", slog.Int("songId", id))
-	}
-	if changeVerse != nil {
-		err := s.versesRepository.ChangeVerse(changeVerse)
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+		if err == nil && operation.Kind != "" && !models.IsVerseKind(operation.Kind) {
+			err = fmt.Errorf("unknown verse kind %q", operation.Kind)
 		}
-		s.logger.Info("Changed the verse of the song", slog.Int("songId", id))
-	}
-	if deleteVerseId != 0 {
-		err := s.versesRepository.DeleteVerse(id, deleteVerseId)
 		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+			return fmt.Errorf("operation %d (%s): %w", i, operation.Op, err)
 		}
-		s.logger.Info("Deleted verse from the song", slog.Int("songId", id))
 	}
 	return nil
 }
 
-func (s *SongService) AddSong(group string, song string, songData models.ApiMusicResponse) (int, error) {
+// AddSong adds a song with the metadata from the metadata provider to the library. If the group is not
+// in the library yet, existing groups with similar names are returned so the caller can spot a misspelling
+func (s *SongService) AddSong(ctx context.Context, group string, song string, actor models.Actor) (int, []models.Group, error) {
 	const op = "service.song.AddSong"
-	verses := strings.Split(songData.Text, "\n\n")
-	releaseDate, err := time.Parse("02.01.2006", songData.ReleaseDate)
+	songData, err := s.metadataProvider.GetSongInfo(ctx, group, song)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
 	}
+	s.logger.Debug("Song metadata", slog.String("group", group), slog.String("song", song),
+		slog.Any("data", songData))
 
-	id, err := s.songRepository.AddSong(group, song, releaseDate, verses, songData.Link)
+	releaseDate, verses, err := songMetadata(songData)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	similarGroups, err := s.songRepository.GetSimilarGroups(group, similarGroupsLimit)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, _, err := s.songRepository.AddSong([]string{group}, song, &releaseDate, verses, songData.Link, actor)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(similarGroups) > 0 {
+		s.logger.Info("Song added with a new group similar to existing ones",
+			slog.Int("songId", id), slog.String("group", group), slog.Any("similarGroups", similarGroups))
+	}
+	return id, similarGroups, nil
+}
+
+// songMetadata returns the release date and verses of the song metadata
+func songMetadata(info models.ApiMusicResponse) (time.Time, []models.VerseDraft, error) {
+	releaseDate, err := time.Parse(models.DateLayout, info.ReleaseDate)
+	if err != nil {
+		mlErr := errors.NewMusicLibraryError(errors.BadGatewayError, fmt.Errorf("bad release date of the song: %w", err))
+		return time.Time{}, nil, mlErr
+	}
+	return releaseDate, splitVerses(info.Text), nil
+}
+
+// splitVerses splits lyrics into verses by blank lines
+func splitVerses(text string) []models.VerseDraft {
+	paragraphs := strings.Split(text, "\n\n")
+	verses := make([]models.VerseDraft, 0, len(paragraphs))
+	for _, paragraph := range paragraphs {
+		verses = append(verses, models.VerseDraft{Text: paragraph, Kind: models.VerseKindVerse})
+	}
+	return markRepeats(verses)
+}
+
+// markRepeats treats a verse whose text occurs earlier in the song as a chorus:
+// it is stored as a repeat of the first occurrence and keeps only its own timings
+func markRepeats(verses []models.VerseDraft) []models.VerseDraft {
+	firstOccurrences := make(map[string]int)
+	for i := range verses {
+		key := strings.TrimSpace(verses[i].Text)
+		first, repeated := firstOccurrences[key]
+		if !repeated || key == "" {
+			firstOccurrences[key] = i
+			continue
+		}
+		verses[first].Kind = models.VerseKindChorus
+		verses[i].Kind = models.VerseKindChorus
+		verses[i].Text = ""
+		verses[i].Repeats = &first
 	}
-	return id, nil
+	return verses
 }
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+)
+
+type TrashRepository interface {
+	GetTrash(limit int, offset int) ([]models.SongDBFormat, error)
+	RestoreSong(id int, actor models.Actor) error
+	PurgeSong(id int, actor models.Actor) error
+}
+
+type TrashService struct {
+	logger          *slog.Logger
+	trashRepository TrashRepository
+}
+
+func NewTrashService(logger *slog.Logger, t TrashRepository) *TrashService {
+	return &TrashService{
+		logger:          logger,
+		trashRepository: t,
+	}
+}
+
+// GetTrash returns a page of deleted songs, the latest deleted first
+func (t *TrashService) GetTrash(limit int, page int) (int, []models.Song, error) {
+	const op = "service.trash.GetTrash"
+	offset := page * limit
+	songsData, err := t.trashRepository.GetTrash(limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	songs := collectSongs(songsData)
+	return len(songs), songs, nil
+}
+
+func (t *TrashService) RestoreSong(id int, actor models.Actor) error {
+	const op = "service.trash.RestoreSong"
+	err := t.trashRepository.RestoreSong(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	t.logger.Info("Song restored from trash", slog.Int("songId", id))
+	return nil
+}
+
+func (t *TrashService) PurgeSong(id int, actor models.Actor) error {
+	const op = "service.trash.PurgeSong"
+	err := t.trashRepository.PurgeSong(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	t.logger.Info("Song purged", slog.Int("songId", id))
+	return nil
+}
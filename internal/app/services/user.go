@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"github.com/nosikmy/music-library/internal/app/errors"
+	"github.com/nosikmy/music-library/internal/app/models"
+	"log/slog"
+	"strings"
+)
+
+type UserRepository interface {
+	AddUser(username string, passwordHash string, actor models.Actor) (int, error)
+	GetUser(id int) (models.User, error)
+	GetUserByName(username string) (models.User, error)
+	GetUsers(limit int, offset int) ([]models.User, error)
+	ChangeUserRole(id int, role string, actor models.Actor) error
+	DeleteUser(id int, actor models.Actor) error
+}
+
+// RolePolicy tells which roles can be given to users
+type RolePolicy interface {
+	HasRole(role string) bool
+	Roles() []string
+}
+
+type UserService struct {
+	logger         *slog.Logger
+	userRepository UserRepository
+	policy         RolePolicy
+}
+
+func NewUserService(logger *slog.Logger, u UserRepository, p RolePolicy) *UserService {
+	return &UserService{
+		logger:         logger,
+		userRepository: u,
+		policy:         p,
+	}
+}
+
+func (u *UserService) GetUsers(limit int, page int) (int, []models.User, error) {
+	const op = "service.user.GetUsers"
+	offset := page * limit
+	users, err := u.userRepository.GetUsers(limit, offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(users), users, nil
+}
+
+// ChangeRole gives the user a role of the policy, the user gets it with the next tokens
+func (u *UserService) ChangeRole(id int, role string, actor models.Actor) error {
+	const op = "service.user.ChangeRole"
+	if !u.policy.HasRole(role) {
+		mlErr := errors.NewMusicLibraryError(errors.BadRequestError,
+			fmt.Errorf("unknown role %q, roles are %s", role, strings.Join(u.policy.Roles(), ", ")))
+		return fmt.Errorf("%s: %w", op, mlErr)
+	}
+	err := u.userRepository.ChangeUserRole(id, role, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	u.logger.Info("User role changed", slog.Int("userId", id), slog.String("role", role))
+	return nil
+}
+
+// ChangeRoleByName gives the user with the username a role of the policy
+func (u *UserService) ChangeRoleByName(username string, role string, actor models.Actor) error {
+	const op = "service.user.ChangeRoleByName"
+	user, err := u.userRepository.GetUserByName(username)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = u.ChangeRole(user.Id, role, actor); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteUser deletes the user, its tokens stop working when they have to be refreshed
+func (u *UserService) DeleteUser(id int, actor models.Actor) error {
+	const op = "service.user.DeleteUser"
+	err := u.userRepository.DeleteUser(id, actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	u.logger.Info("User deleted", slog.Int("userId", id))
+	return nil
+}
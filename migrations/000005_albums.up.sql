@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS albums
+(
+    id           SERIAL PRIMARY KEY,
+    name         VARCHAR NOT NULL,
+    release_date TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS album_tracks
+(
+    album_id     INTEGER NOT NULL,
+    song_id      INTEGER NOT NULL,
+    disc_number  INTEGER NOT NULL DEFAULT 1,
+    track_number INTEGER NOT NULL,
+    PRIMARY KEY (album_id, disc_number, track_number),
+    UNIQUE (album_id, song_id),
+    FOREIGN KEY (album_id) REFERENCES albums (id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS refresh_song_search_vector(INTEGER);
+DROP INDEX IF EXISTS songs_search_vector_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_vector
\ No newline at end of file
@@ -0,0 +1,30 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE INDEX IF NOT EXISTS songs_search_vector_idx ON songs USING GIN (search_vector);
+
+CREATE OR REPLACE FUNCTION refresh_song_search_vector(p_song_id INTEGER) RETURNS VOID AS
+$$
+WITH RECURSIVE verse_chain AS (
+    SELECT v.id, v.text, v.next
+    FROM verses v
+             INNER JOIN songs s ON v.id = s.first_verse_id
+    WHERE s.id = p_song_id
+
+    UNION ALL
+
+    SELECT v.id, v.text, v.next
+    FROM verses v
+             INNER JOIN verse_chain vc ON v.id = vc.next
+)
+UPDATE songs
+SET search_vector =
+        setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE((SELECT string_agg(g.name, ' ')
+                                                  FROM songs_groups sg
+                                                           JOIN groups g ON g.id = sg.group_id
+                                                  WHERE sg.song_id = p_song_id), '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE((SELECT string_agg(text, ' ') FROM verse_chain), '')), 'C')
+WHERE id = p_song_id
+$$ LANGUAGE SQL;
+
+SELECT refresh_song_search_vector(id) FROM songs
\ No newline at end of file
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS songs_name_trgm_idx ON songs USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS groups_name_trgm_idx ON groups USING GIN (name gin_trgm_ops)
\ No newline at end of file
@@ -0,0 +1,10 @@
+UPDATE verses r
+SET text = o.text
+FROM verses o
+WHERE r.repeat_of = o.id;
+
+DROP INDEX IF EXISTS verses_repeat_of_idx;
+ALTER TABLE verses
+    DROP COLUMN IF EXISTS repeat_of,
+    DROP COLUMN IF EXISTS label,
+    DROP COLUMN IF EXISTS kind
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE verses
+    ADD COLUMN IF NOT EXISTS kind      VARCHAR NOT NULL DEFAULT 'verse'
+        CHECK (kind IN ('intro', 'verse', 'chorus', 'bridge', 'outro', 'other')),
+    ADD COLUMN IF NOT EXISTS label     VARCHAR,
+    ADD COLUMN IF NOT EXISTS repeat_of INTEGER REFERENCES verses (id);
+
+CREATE INDEX IF NOT EXISTS verses_repeat_of_idx ON verses (repeat_of)
\ No newline at end of file
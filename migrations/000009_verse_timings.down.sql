@@ -0,0 +1,4 @@
+ALTER TABLE verses
+    DROP COLUMN IF EXISTS line_starts_ms,
+    DROP COLUMN IF EXISTS end_ms,
+    DROP COLUMN IF EXISTS start_ms
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE verses
+    ADD COLUMN IF NOT EXISTS start_ms       INTEGER,
+    ADD COLUMN IF NOT EXISTS end_ms         INTEGER,
+    ADD COLUMN IF NOT EXISTS line_starts_ms JSONB
\ No newline at end of file
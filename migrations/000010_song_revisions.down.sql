@@ -0,0 +1 @@
+DROP TABLE IF EXISTS song_revisions
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS song_revisions
+(
+    id         SERIAL PRIMARY KEY,
+    song_id    INTEGER   NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    revision   INTEGER   NOT NULL,
+    editor     VARCHAR   NOT NULL,
+    action     VARCHAR   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    before     JSONB     NOT NULL,
+    after      JSONB     NOT NULL,
+    UNIQUE (song_id, revision)
+)
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS songs_deleted_at_idx;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS deleted_at
\ No newline at end of file
@@ -0,0 +1,18 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS songs_deleted_at_idx ON songs (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- verses left behind by songs deleted before the trash existed
+WITH RECURSIVE verse_chain AS (
+    SELECT v.id, v.next
+    FROM verses v
+             INNER JOIN songs s ON v.id = s.first_verse_id
+
+    UNION ALL
+
+    SELECT v.id, v.next
+    FROM verses v
+             INNER JOIN verse_chain vc ON v.id = vc.next
+)
+DELETE FROM verses WHERE id NOT IN (SELECT id FROM verse_chain)
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+-- songs edited since the history was introduced were last updated by their latest revision
+UPDATE songs s
+SET updated_at = r.created_at
+FROM (SELECT song_id, MAX(created_at) AS created_at FROM song_revisions GROUP BY song_id) r
+WHERE s.id = r.song_id
\ No newline at end of file
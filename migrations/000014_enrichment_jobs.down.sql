@@ -0,0 +1 @@
+DROP TABLE IF EXISTS enrichment_jobs
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS enrichment_jobs
+(
+    id           SERIAL PRIMARY KEY,
+    song_id      INTEGER   NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    group_name   VARCHAR   NOT NULL,
+    song_name    VARCHAR   NOT NULL,
+    status       VARCHAR   NOT NULL DEFAULT 'pending',
+    attempts     INTEGER   NOT NULL DEFAULT 0,
+    last_error   VARCHAR,
+    run_at       TIMESTAMP NOT NULL DEFAULT now(),
+    locked_until TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_jobs_run_at_idx ON enrichment_jobs (run_at) WHERE status = 'pending'
\ No newline at end of file
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS playlists
+(
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS playlist_items
+(
+    id          SERIAL PRIMARY KEY,
+    playlist_id INTEGER NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    song_id     INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    -- positions are shifted by a single statement, so uniqueness is checked at commit
+    UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS playlist_items_song_id_idx ON playlist_items (song_id)
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users
+(
+    id            SERIAL PRIMARY KEY,
+    username      VARCHAR   NOT NULL UNIQUE,
+    password_hash VARCHAR   NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT now()
+)
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR NOT NULL DEFAULT 'viewer'
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    name         VARCHAR   NOT NULL,
+    prefix       VARCHAR   NOT NULL UNIQUE,
+    key_hash     VARCHAR   NOT NULL,
+    scopes       JSONB     NOT NULL,
+    created_by   INTEGER   REFERENCES users (id) ON DELETE SET NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP
+)
\ No newline at end of file
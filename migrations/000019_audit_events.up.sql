@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_events
+(
+    id          SERIAL PRIMARY KEY,
+    actor       VARCHAR   NOT NULL,
+    action      VARCHAR   NOT NULL,
+    entity_type VARCHAR   NOT NULL,
+    -- no foreign key, the events outlive the purged entities
+    entity_id   INTEGER   NOT NULL,
+    request_id  VARCHAR   NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+    before      JSONB,
+    after       JSONB
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON audit_events (entity_type, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at)
\ No newline at end of file